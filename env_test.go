@@ -85,7 +85,7 @@ func TestEnv_UnmarshallErrors(t *testing.T) {
 					"Databases_0_NEST_TIMEOUT": "P30s",
 				},
 			},
-			expected: `environment variable 'Databases_0_NEST_TIMEOUT' failed to parse because time: invalid duration P30s`,
+			expected: `environment variable 'Databases_0_NEST_TIMEOUT' failed to parse because time: invalid duration "P30s"`,
 		},
 		"int field does not parse": {
 			env: &envMock{
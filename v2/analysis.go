@@ -0,0 +1,255 @@
+package v2
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// envIndexPlaceholder stands in for slice indexes in names that are computed from types rather than values
+const envIndexPlaceholder = "{N}"
+
+// analyzedField is a field that reads its value from a single environment variable, or a slice or map, which reads
+// the names of its elements to find its length or keys
+type analyzedField struct {
+	// structPath is the path to the field. Slice elements are shown with empty brackets: Databases[].Host
+	structPath string
+	// envNames are the variables the field can be read from, with envIndexPlaceholder in place of slice indexes
+	envNames []envNameCandidate
+	// container is true when envNames are the variables read to find the elements of a slice or map, rather than a value
+	container bool
+}
+
+// analyzeType lists every field in the structure type t that reads a value from an environment variable,
+// using the same naming rules as structToEnvPaths
+func (e *envInternal) analyzeType(t reflect.Type) (fields []analyzedField, err error) {
+	err = e.analyzeStruct(t, "", e.rootEnvNames(), make(map[reflect.Type]bool), &fields)
+	return
+}
+
+func (e *envInternal) analyzeStruct(t reflect.Type, structPath string, names []envNameCandidate, visiting map[reflect.Type]bool, fields *[]analyzedField) (err error) {
+	if visiting[t] {
+		// recursive types are only as deep as the values that fill them, so stop at the first repeat
+		return
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldPath := field.Name
+		if structPath != "" {
+			fieldPath = structPath + "." + field.Name
+		}
+		var tag envTag
+		tag, err = parseEnvTag(field)
+		if err != nil {
+			return newTagError(fieldPath, field.Tag.Get("env"), err)
+		}
+		if isSkipped(field, tag) {
+			continue
+		}
+		if tag.absolute && strings.Contains(structPath, "[]") {
+			return newTagError(fieldPath, field.Tag.Get("env"), errAbsoluteInElement)
+		}
+		if (tag.secret || tag.hasDefault) && !e.holdsValues(field.Type) {
+			option := "secret"
+			if !tag.secret {
+				option = "default"
+			}
+			return newTagError(fieldPath, field.Tag.Get("env"), fmt.Errorf("option '%s' can only be used on fields that hold values, not on structures", option))
+		}
+		err = e.analyzeValue(field.Type, fieldPath, e.appendFieldNames(names, field, tag), visiting, fields)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// holdsValues is true for types whose values are parsed from variables: values the registry or UnmarshalText can parse,
+// and pointers, slices and maps of them. Options about the value of a field, such as secret, mean nothing for others
+func (e *envInternal) holdsValues(t reflect.Type) bool {
+	if e.isParseable(reflect.New(t).Interface()) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return e.holdsValues(t.Elem())
+	}
+	return false
+}
+
+func (e *envInternal) analyzeValue(t reflect.Type, structPath string, names []envNameCandidate, visiting map[reflect.Type]bool, fields *[]analyzedField) (err error) {
+	if e.isParseable(reflect.New(t).Interface()) {
+		*fields = append(*fields, e.analyzedValue(structPath, names))
+		return
+	}
+	if e.isStructMap(t) {
+		*fields = append(*fields, e.analyzedStructMap(structPath, names))
+		elementNames := make([]envNameCandidate, len(names))
+		for i, name := range names {
+			elementNames[i] = envNameCandidate{
+				envName:    e.separators.appendField(name.envName, envKeyPlaceholder),
+				deprecated: name.deprecated,
+			}
+		}
+		return e.analyzeValue(t.Elem(), structPath+"[]", elementNames, visiting, fields)
+	}
+	if e.isScalarMap(t) {
+		*fields = append(*fields, e.analyzedScalarMap(structPath, names))
+		return
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return e.analyzeValue(t.Elem(), structPath, names, visiting, fields)
+	case reflect.Struct:
+		return e.analyzeStruct(t, structPath, names, visiting, fields)
+	case reflect.Slice:
+		*fields = append(*fields, e.analyzedSlice(structPath, names, e.isParseable(reflect.New(t.Elem()).Interface())))
+		elementNames := make([]envNameCandidate, len(names))
+		for i, name := range names {
+			elementNames[i] = envNameCandidate{
+				envName:    e.separators.appendIndexText(name.envName, envIndexPlaceholder),
+				deprecated: name.deprecated,
+			}
+		}
+		return e.analyzeValue(t.Elem(), structPath+"[]", elementNames, visiting, fields)
+	default:
+		*fields = append(*fields, e.analyzedValue(structPath, names))
+	}
+	return
+}
+
+// analyzedValue is a field that reads a value from names, or, if a fileSuffix is configured, from the files they name
+func (e *envInternal) analyzedValue(structPath string, names []envNameCandidate) analyzedField {
+	field := analyzedField{structPath: structPath, envNames: names}
+	if e.fileSuffix != "" {
+		field.envNames = append([]envNameCandidate{}, names...)
+		for _, name := range names {
+			field.envNames = append(field.envNames, envNameCandidate{envName: envName{name: name.name + e.fileSuffix}})
+		}
+	}
+	return field
+}
+
+// analyzedSlice is a slice named names. SliceLen counts every variable with an index after the name,
+// such as Databases_0 and Databases_0_Anything, not only the names of the fields of its elements.
+// Slices of values can also be set as a list, using the names themselves
+func (e *envInternal) analyzedSlice(structPath string, names []envNameCandidate, list bool) analyzedField {
+	field := analyzedField{structPath: structPath, container: true}
+	if list {
+		field.envNames = append(field.envNames, e.analyzedValue(structPath, names).envNames...)
+	}
+	for _, name := range names {
+		indexed := e.separators.indexPrefix(name.envName) + envIndexPlaceholder
+		field.envNames = append(field.envNames,
+			envNameCandidate{envName: envName{name: indexed}},
+			envNameCandidate{envName: envName{name: indexed + e.separators.indexFollowing() + envAnyPlaceholder}},
+		)
+	}
+	return field
+}
+
+// checkCollisions reports fields in the structure type t that read from the same environment variable,
+// such as a field promoted from an inline structure with the same name as a field of the parent.
+// Aliases and deprecated names are included, so an old name cannot be reused by another field.
+// Slice indexes match any number, so a field tagged Databases_0_Host collides with the Host of Databases elements
+func (e *envInternal) checkCollisions(t reflect.Type) (err error) {
+	fields, err := e.analyzeType(t)
+	if err != nil {
+		return
+	}
+	var collisions []NameCollision
+	for i := range fields {
+		for j := i + 1; j < len(fields); j++ {
+			if fields[i].contains(fields[j]) {
+				// the elements of a slice always read names the slice counts
+				continue
+			}
+			if envPath, collide := e.fieldsCollide(fields[i], fields[j]); collide {
+				collisions = append(collisions, NameCollision{
+					StructPaths: [2]string{fields[i].structPath, fields[j].structPath},
+					EnvPath:     envPath,
+				})
+			}
+		}
+	}
+	if len(collisions) != 0 {
+		err = &NameCollisionError{Collisions: collisions}
+	}
+	return
+}
+
+// analyzedStructMap is a map named names. Every variable with a key and a Field separator after the name is read
+// to find its keys, such as Databases_primary_Anything
+func (e *envInternal) analyzedStructMap(structPath string, names []envNameCandidate) analyzedField {
+	field := analyzedField{structPath: structPath, container: true}
+	for _, name := range names {
+		field.envNames = append(field.envNames, envNameCandidate{
+			envName: envName{name: e.separators.appendField(name.envName, envKeyPlaceholder).name + e.separators.Field + envAnyPlaceholder},
+		})
+	}
+	return field
+}
+
+// analyzedScalarMap is a map of values named names. It reads a list from names, and entries from every variable
+// named after one of the names, such as Labels_Anything
+func (e *envInternal) analyzedScalarMap(structPath string, names []envNameCandidate) analyzedField {
+	field := e.analyzedValue(structPath, names)
+	for _, name := range names {
+		field.envNames = append(field.envNames, envNameCandidate{
+			envName: envName{name: e.separators.appendField(name.envName, "").name + envAnyPlaceholder},
+		})
+	}
+	return field
+}
+
+// contains is true when other is within the slice or map f
+func (f analyzedField) contains(other analyzedField) bool {
+	return f.container && strings.HasPrefix(other.structPath, f.structPath+"[]")
+}
+
+// fieldsCollide finds the first name of a that could also be a name of b. Identical names are reported as they are,
+// with envIndexPlaceholder for indexes. Otherwise, an example of a name they could both read is reported
+func (e *envInternal) fieldsCollide(a analyzedField, b analyzedField) (envPath string, collide bool) {
+	for _, aName := range a.envNames {
+		for _, bName := range b.envNames {
+			if aName.name == bName.name || (e.caseInsensitive && strings.EqualFold(aName.name, bName.name)) {
+				return aName.name, true
+			}
+		}
+	}
+	for _, aName := range a.envNames {
+		for _, bName := range b.envNames {
+			envPath, collide = namePatternsOverlap(e.separators.namePattern(aName.name), e.separators.namePattern(bName.name), e.caseInsensitive)
+			if collide {
+				return
+			}
+		}
+	}
+	return
+}
+
+// NameCollision is a pair of fields that read from the same environment variable
+type NameCollision struct {
+	// StructPaths are the paths to the colliding fields. Slice elements are shown with empty brackets: Databases[].Host
+	StructPaths [2]string
+	// EnvPath is the name of the variable, with {N} in place of slice indexes when both fields read the same name.
+	// When the names only overlap, such as Databases_{N}_Host and Databases_0_Host, this is a name both could read
+	EnvPath string
+}
+
+// NameCollisionError is returned when several fields in the destination structure read from the same environment
+// variable. This is a mistake in the program, usually caused by env tags or inline structures
+type NameCollisionError struct {
+	Collisions []NameCollision
+}
+
+func (n *NameCollisionError) Error() string {
+	messages := make([]string, len(n.Collisions))
+	for i, collision := range n.Collisions {
+		messages[i] = fmt.Sprintf("fields '%s' and '%s' both read environment variable '%s'",
+			collision.StructPaths[0], collision.StructPaths[1], collision.EnvPath)
+	}
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,50 @@
+package v2
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NewArgsEnv creates a reader from command-line arguments, named exactly like environment variables, so that any
+// setting can be overridden with, for example, --Databases_0_Host=db2 or --Databases_0_Host db2.
+// args should not include the program name, pass os.Args[1:].
+//
+// Arguments that do not begin with "--" are not flags and are returned in positional, in order. Everything after
+// a "--" argument is positional. If a flag is repeated, the last value wins.
+// Layer this above OsEnv to let the command-line override the environment:
+//
+//	args, positional, err := NewArgsEnv(os.Args[1:])
+//	reader := NewLayeredEnv(args, &OsEnv{})
+func NewArgsEnv(args []string) (reader *MapEnv, positional []string, err error) {
+	values := make(map[string]string)
+	positional = []string{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(arg, "--") {
+			positional = append(positional, arg)
+			continue
+		}
+		key := arg[len("--"):]
+		value := ""
+		if equals := strings.IndexByte(key, '='); equals != -1 {
+			key, value = key[:equals], key[equals+1:]
+		} else if i+1 < len(args) && !strings.HasPrefix(args[i+1], "--") {
+			i++
+			value = args[i]
+		} else {
+			err = fmt.Errorf("argument '%s' is missing a value, use %s=value or %s value", arg, arg, arg)
+			return
+		}
+		if key == "" {
+			err = fmt.Errorf("argument '%s' is missing a name", arg)
+			return
+		}
+		values[key] = value
+	}
+	reader = NewMapEnv(values)
+	return
+}
@@ -0,0 +1,86 @@
+package v2
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewArgsEnv(t *testing.T) {
+	cases := map[string]struct {
+		args               []string
+		expected           map[string]string
+		expectedPositional []string
+	}{
+		"empty": {
+			expected:           map[string]string{},
+			expectedPositional: []string{},
+		},
+		"equals form": {
+			args:               []string{"--Databases_0_Host=db2", "--Name=a=b", "--Empty="},
+			expected:           map[string]string{"Databases_0_Host": "db2", "Name": "a=b", "Empty": ""},
+			expectedPositional: []string{},
+		},
+		"separate value": {
+			args:               []string{"--Databases_0_Host", "db2", "--ThreadCount", "-1"},
+			expected:           map[string]string{"Databases_0_Host": "db2", "ThreadCount": "-1"},
+			expectedPositional: []string{},
+		},
+		"positional": {
+			args:               []string{"serve", "--Name", "a", "-v", "-", "input.txt"},
+			expected:           map[string]string{"Name": "a"},
+			expectedPositional: []string{"serve", "-v", "-", "input.txt"},
+		},
+		"terminator": {
+			args:               []string{"--Name=a", "--", "--Name=b", "file"},
+			expected:           map[string]string{"Name": "a"},
+			expectedPositional: []string{"--Name=b", "file"},
+		},
+		"last wins": {
+			args:               []string{"--Name=a", "--Name", "b"},
+			expected:           map[string]string{"Name": "b"},
+			expectedPositional: []string{},
+		},
+	}
+
+	for caseName, c := range cases {
+		t.Run(caseName, func(t *testing.T) {
+			reader, positional, err := NewArgsEnv(c.args)
+			if !assert.NoError(t, err) {
+				return
+			}
+			actual := make(map[string]string)
+			for _, key := range reader.Keys("") {
+				actual[key] = reader.Get(key)
+			}
+			assert.Equal(t, c.expected, actual)
+			assert.Equal(t, c.expectedPositional, positional)
+		})
+	}
+}
+
+func TestNewArgsEnvErrors(t *testing.T) {
+	cases := map[string]struct {
+		args     []string
+		expected string
+	}{
+		"missing value at end": {
+			args:     []string{"--Name"},
+			expected: "argument '--Name' is missing a value, use --Name=value or --Name value",
+		},
+		"missing value before flag": {
+			args:     []string{"--Name", "--ThreadCount=1"},
+			expected: "argument '--Name' is missing a value, use --Name=value or --Name value",
+		},
+		"missing name": {
+			args:     []string{"--=value"},
+			expected: "argument '--=value' is missing a name",
+		},
+	}
+
+	for caseName, c := range cases {
+		t.Run(caseName, func(t *testing.T) {
+			_, _, err := NewArgsEnv(c.args)
+			assert.EqualError(t, err, c.expected)
+		})
+	}
+}
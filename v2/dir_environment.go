@@ -0,0 +1,45 @@
+package v2
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// NewDirEnv creates a reader with one variable per file in dir. The file name is the variable name and the contents of
+// the file, without trailing newlines, is the value. This is the layout Kubernetes uses when mounting ConfigMaps and
+// Secrets as volumes.
+//
+// Hidden entries, those beginning with a dot, are ignored. Kubernetes uses these for the "..data" symlink and the
+// timestamped directories behind it, while the visible entries are symlinks that point through "..data" to the real files.
+// Symlinks are followed and directories are skipped.
+//
+// The directory is read once, when this is called. Call NewDirEnv again to pick up changes to the mounted files.
+func NewDirEnv(dir string) (reader *MapEnv, err error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	values := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		fileName := filepath.Join(dir, entry.Name())
+		var info os.FileInfo
+		info, err = os.Stat(fileName)
+		if err != nil {
+			return
+		}
+		if info.IsDir() {
+			continue
+		}
+		values[entry.Name()], err = readValueFile(fileName)
+		if err != nil {
+			return
+		}
+	}
+	reader = NewMapEnv(values)
+	return
+}
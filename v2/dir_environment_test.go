@@ -0,0 +1,46 @@
+package v2
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewDirEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "configmap")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	// Reproduce the layout kubelet creates for a mounted ConfigMap
+	dataDir := filepath.Join(dir, "..2020_01_01_00_00_00.000000000")
+	assert.NoError(t, os.Mkdir(dataDir, 0700))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dataDir, "Name"), []byte("SuperServer\n"), 0600))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dataDir, "Databases_0_Host"), []byte("example.com"), 0600))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dataDir, "Databases_1_Host"), []byte("replica.example.com"), 0600))
+	assert.NoError(t, os.Symlink(filepath.Base(dataDir), filepath.Join(dir, "..data")))
+	for _, name := range []string{"Name", "Databases_0_Host", "Databases_1_Host"} {
+		assert.NoError(t, os.Symlink(filepath.Join("..data", name), filepath.Join(dir, name)))
+	}
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "subdir"), 0700))
+
+	reader, err := NewDirEnv(dir)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"Databases_0_Host", "Databases_1_Host", "Name"}, reader.Keys(""))
+	assert.Equal(t, "SuperServer", reader.Get("Name"))
+
+	actual := &appConfigMock{}
+	err = NewWithEnvReader(reader).Unmarshall(actual)
+	assert.NoError(t, err)
+	assert.Len(t, actual.Databases, 2)
+	assert.True(t, actual.Name.IsSet())
+
+	_, err = NewDirEnv(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
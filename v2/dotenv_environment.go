@@ -0,0 +1,328 @@
+package v2
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// DotEnvReader reads environment variables from one or more dotenv files
+//
+// Supported syntax, one assignment per line:
+//
+//	# comments start with a hash
+//	export NAME=value            # "export " is optional and ignored
+//	NAME = unquoted value        # surrounding whitespace is trimmed, " #" starts a comment
+//	NAME='single quoted'         # taken literally, may span several lines
+//	NAME="double quoted\n"       # supports \n \r \t \" \\ \$ and \` escapes, may span several lines
+//
+// When the same name appears more than once, the last assignment wins. This includes assignments across files.
+type DotEnvReader struct {
+	values map[string]string
+}
+
+// NewDotEnvReader creates a reader with the contents of the files at paths.
+// Files are read in order, so values in later files override the values in earlier files.
+func NewDotEnvReader(paths ...string) (reader *DotEnvReader, err error) {
+	reader = &DotEnvReader{}
+	for _, path := range paths {
+		err = reader.parseFile(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+func (d *DotEnvReader) parseFile(path string) (err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	return d.Parse(path, file)
+}
+
+// Parse reads dotenv formatted content from src and adds the variables to this reader, overriding any already set.
+// name is used in error messages to identify the source, such as the file name
+func (d *DotEnvReader) Parse(name string, src io.Reader) (err error) {
+	var content bytes.Buffer
+	_, err = content.ReadFrom(src)
+	if err != nil {
+		return
+	}
+	values, err := parseDotEnv(name, content.String())
+	if err != nil {
+		return
+	}
+	if d.values == nil {
+		d.values = make(map[string]string, len(values))
+	}
+	for key, value := range values {
+		d.values[key] = value
+	}
+	return
+}
+
+func (d *DotEnvReader) Get(envNamed string) string {
+	return d.values[envNamed]
+}
+
+func (d *DotEnvReader) Lookup(envNamed string) (value string, ok bool) {
+	value, ok = d.values[envNamed]
+	return
+}
+
+func (d *DotEnvReader) Keys(prefix string) (out []string) {
+	keys := make([]string, 0, len(d.values))
+	for key := range d.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return SelectKeysWithPrefix(keys, prefix)
+}
+
+// DotEnvSyntaxError is returned when a dotenv file cannot be parsed
+type DotEnvSyntaxError struct {
+	// File is the name of the file, or source, that failed to parse
+	File string
+	// Line is the 1-based line number on which the broken assignment starts
+	Line int
+	msg  string
+}
+
+func (d *DotEnvSyntaxError) Error() string {
+	return fmt.Sprintf("dotenv file '%s' line %d: %s", d.File, d.Line, d.msg)
+}
+
+// dotEnvParser holds the state of parsing a single dotenv source
+type dotEnvParser struct {
+	name    string
+	content string
+	pos     int
+	line    int
+}
+
+func parseDotEnv(name string, content string) (values map[string]string, err error) {
+	p := &dotEnvParser{
+		name:    name,
+		content: strings.ReplaceAll(content, "\r\n", "\n"),
+		line:    1,
+	}
+	values = make(map[string]string)
+	for {
+		p.skipBlank()
+		if p.atEnd() {
+			return
+		}
+		switch p.peek() {
+		case '\n':
+			p.advance()
+			continue
+		case '#':
+			p.skipToEndOfLine()
+			continue
+		}
+		var key, value string
+		key, value, err = p.assignment()
+		if err != nil {
+			return nil, err
+		}
+		values[key] = value
+	}
+}
+
+// assignment parses a single NAME=value entry, starting at the beginning of the name
+func (p *dotEnvParser) assignment() (key string, value string, err error) {
+	startLine := p.line
+	if strings.HasPrefix(p.content[p.pos:], "export") {
+		rest := p.content[p.pos+len("export"):]
+		if len(rest) > 0 && (rest[0] == ' ' || rest[0] == '\t') {
+			p.pos += len("export")
+			p.skipBlank()
+		}
+	}
+	key = p.variableName()
+	if key == "" {
+		err = p.errorf(startLine, "expected a variable name")
+		return
+	}
+	p.skipBlank()
+	if p.atEnd() || p.peek() != '=' {
+		err = p.errorf(startLine, "expected '=' after '%s'", key)
+		return
+	}
+	p.advance()
+	p.skipBlank()
+	if p.atEnd() {
+		return
+	}
+	switch p.peek() {
+	case '\'':
+		value, err = p.singleQuoted(startLine)
+	case '"':
+		value, err = p.doubleQuoted(startLine)
+	default:
+		value = p.unquoted()
+		return
+	}
+	if err != nil {
+		return
+	}
+	p.skipBlank()
+	if p.atEnd() {
+		return
+	}
+	switch p.peek() {
+	case '\n':
+		p.advance()
+	case '#':
+		p.skipToEndOfLine()
+	default:
+		err = p.errorf(p.line, "unexpected characters after the quoted value of '%s'", key)
+	}
+	return
+}
+
+// variableName reads a variable name: [a-zA-Z_]+[a-zA-Z0-9_]*
+func (p *dotEnvParser) variableName() string {
+	start := p.pos
+	for !p.atEnd() {
+		c := p.peek()
+		isLetter := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		isDigit := c >= '0' && c <= '9'
+		if !isLetter && !(isDigit && p.pos > start) {
+			break
+		}
+		p.advance()
+	}
+	return p.content[start:p.pos]
+}
+
+// unquoted reads the rest of the line, stopping at a comment, and trims the surrounding whitespace.
+// A # only starts a comment after whitespace, so KEY=#value keeps the #, while KEY= #comment is empty.
+// The value always follows the =, so the character before it is the = or the whitespace after it
+func (p *dotEnvParser) unquoted() string {
+	start := p.pos
+	end := -1
+	for !p.atEnd() && p.peek() != '\n' {
+		if p.peek() == '#' && isDotEnvBlank(p.content[p.pos-1]) {
+			end = p.pos
+			p.skipToEndOfLine()
+			break
+		}
+		p.advance()
+	}
+	if end == -1 {
+		end = p.pos
+		if !p.atEnd() {
+			p.advance()
+		}
+	}
+	return strings.TrimRight(p.content[start:end], " \t")
+}
+
+// singleQuoted reads everything up to the closing quote literally
+func (p *dotEnvParser) singleQuoted(startLine int) (value string, err error) {
+	p.advance()
+	start := p.pos
+	for !p.atEnd() && p.peek() != '\'' {
+		p.advance()
+	}
+	if p.atEnd() {
+		err = p.errorf(startLine, "unterminated single-quoted value")
+		return
+	}
+	value = p.content[start:p.pos]
+	p.advance()
+	return
+}
+
+// doubleQuoted reads everything up to the closing quote, replacing escape sequences
+func (p *dotEnvParser) doubleQuoted(startLine int) (value string, err error) {
+	p.advance()
+	sb := strings.Builder{}
+	for !p.atEnd() {
+		c := p.peek()
+		p.advance()
+		switch c {
+		case '"':
+			value = sb.String()
+			return
+		case '\\':
+			if p.atEnd() {
+				break
+			}
+			escaped := p.peek()
+			p.advance()
+			switch escaped {
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case '"', '\\', '$', '`':
+				sb.WriteByte(escaped)
+			case '\n':
+				// line continuation, the newline is removed
+			default:
+				sb.WriteByte('\\')
+				sb.WriteByte(escaped)
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	err = p.errorf(startLine, "unterminated double-quoted value")
+	return
+}
+
+func (p *dotEnvParser) atEnd() bool {
+	return p.pos >= len(p.content)
+}
+
+func (p *dotEnvParser) peek() byte {
+	return p.content[p.pos]
+}
+
+// advance moves to the next character, keeping track of the line number
+func (p *dotEnvParser) advance() {
+	if p.content[p.pos] == '\n' {
+		p.line++
+	}
+	p.pos++
+}
+
+func (p *dotEnvParser) skipBlank() {
+	for !p.atEnd() && isDotEnvBlank(p.peek()) {
+		p.advance()
+	}
+}
+
+// skipToEndOfLine moves past the next newline, or to the end of the content if there are no more newlines
+func (p *dotEnvParser) skipToEndOfLine() {
+	for !p.atEnd() && p.peek() != '\n' {
+		p.advance()
+	}
+	if !p.atEnd() {
+		p.advance()
+	}
+}
+
+func (p *dotEnvParser) errorf(line int, format string, args ...interface{}) error {
+	return &DotEnvSyntaxError{
+		File: p.name,
+		Line: line,
+		msg:  fmt.Sprintf(format, args...),
+	}
+}
+
+func isDotEnvBlank(c byte) bool {
+	return c == ' ' || c == '\t'
+}
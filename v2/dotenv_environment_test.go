@@ -0,0 +1,147 @@
+package v2
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wojnosystems/go-optional/v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDotEnvReader_Parse(t *testing.T) {
+	cases := map[string]struct {
+		content  string
+		expected map[string]string
+	}{
+		"empty": {
+			expected: map[string]string{},
+		},
+		"comments and blank lines": {
+			content:  "# a comment\n\n   \n  # indented comment\nNAME=value\n",
+			expected: map[string]string{"NAME": "value"},
+		},
+		"export prefix": {
+			content:  "export NAME=value\nexport\tOTHER=other",
+			expected: map[string]string{"NAME": "value", "OTHER": "other"},
+		},
+		"name called export": {
+			content:  "export=value",
+			expected: map[string]string{"export": "value"},
+		},
+		"unquoted trims and strips comments": {
+			content:  "NAME =  some value  # trailing comment\nHASH=a#b\nEMPTY=\nCOMMENT_ONLY= # nothing",
+			expected: map[string]string{"NAME": "some value", "HASH": "a#b", "EMPTY": "", "COMMENT_ONLY": ""},
+		},
+		"hash at the start of a value": {
+			content:  "COLOR=#ff0000\nPASSWORD=#abc # comment\nTAB=\t#comment",
+			expected: map[string]string{"COLOR": "#ff0000", "PASSWORD": "#abc", "TAB": ""},
+		},
+		"unquoted keeps backslashes": {
+			content:  `PATH=C:\temp\n`,
+			expected: map[string]string{"PATH": `C:\temp\n`},
+		},
+		"single quoted is literal": {
+			content:  `NAME='  a "b" \n # c '  # comment`,
+			expected: map[string]string{"NAME": `  a "b" \n # c `},
+		},
+		"double quoted escapes": {
+			content:  `NAME="tab\there\nnew \"quoted\" \\ \$HOME \q"`,
+			expected: map[string]string{"NAME": "tab\there\nnew \"quoted\" \\ $HOME \\q"},
+		},
+		"multi-line values": {
+			content:  "CERT=\"line1\nline2\"\nKEY='a\nb'\nNEXT=1",
+			expected: map[string]string{"CERT": "line1\nline2", "KEY": "a\nb", "NEXT": "1"},
+		},
+		"double quoted line continuation": {
+			content:  "NAME=\"a\\\nb\"",
+			expected: map[string]string{"NAME": "ab"},
+		},
+		"windows line endings": {
+			content:  "A=1\r\nB=\"2\r\n3\"\r\n",
+			expected: map[string]string{"A": "1", "B": "2\n3"},
+		},
+		"last wins": {
+			content:  "A=1\nA=2",
+			expected: map[string]string{"A": "2"},
+		},
+	}
+
+	for caseName, c := range cases {
+		t.Run(caseName, func(t *testing.T) {
+			reader := &DotEnvReader{}
+			err := reader.Parse("test.env", strings.NewReader(c.content))
+			assert.NoError(t, err)
+			actual := make(map[string]string)
+			for _, key := range reader.Keys("") {
+				actual[key] = reader.Get(key)
+			}
+			assert.Equal(t, c.expected, actual)
+		})
+	}
+}
+
+func TestDotEnvReader_ParseErrors(t *testing.T) {
+	cases := map[string]struct {
+		content  string
+		expected string
+	}{
+		"missing equals": {
+			content:  "A=1\nNAME value",
+			expected: `dotenv file 'test.env' line 2: expected '=' after 'NAME'`,
+		},
+		"bad name": {
+			content:  "A=1\n\n1NAME=value",
+			expected: `dotenv file 'test.env' line 3: expected a variable name`,
+		},
+		"unterminated single quote": {
+			content:  "A=1\nNAME='abc\n\nB=2",
+			expected: `dotenv file 'test.env' line 2: unterminated single-quoted value`,
+		},
+		"unterminated double quote": {
+			content:  "NAME=\"abc\\\"",
+			expected: `dotenv file 'test.env' line 1: unterminated double-quoted value`,
+		},
+		"characters after quote": {
+			content:  "A=1\nNAME=\"abc\n\"def",
+			expected: `dotenv file 'test.env' line 3: unexpected characters after the quoted value of 'NAME'`,
+		},
+	}
+
+	for caseName, c := range cases {
+		t.Run(caseName, func(t *testing.T) {
+			reader := &DotEnvReader{}
+			err := reader.Parse("test.env", strings.NewReader(c.content))
+			assert.EqualError(t, err, c.expected)
+		})
+	}
+}
+
+func TestNewDotEnvReader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dotenv")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	defaults := filepath.Join(dir, "defaults.env")
+	overrides := filepath.Join(dir, "overrides.env")
+	assert.NoError(t, ioutil.WriteFile(defaults, []byte("Name=default\nDatabases_0_Host=localhost\n"), 0600))
+	assert.NoError(t, ioutil.WriteFile(overrides, []byte("Name=override\n"), 0600))
+
+	reader, err := NewDotEnvReader(defaults, overrides)
+	assert.NoError(t, err)
+	assert.Equal(t, "override", reader.Get("Name"))
+	assert.Equal(t, "localhost", reader.Get("Databases_0_Host"))
+	assert.Equal(t, []string{"Databases_0_Host"}, reader.Keys("Databases_"))
+
+	actual := &appConfigMock{}
+	err = NewWithEnvReader(reader).Unmarshall(actual)
+	assert.NoError(t, err)
+	assert.Equal(t, optional.StringFrom("localhost"), actual.Databases[0].Host)
+
+	_, err = NewDotEnvReader(filepath.Join(dir, "missing.env"))
+	assert.Error(t, err)
+}
@@ -1,10 +1,11 @@
 package v2
 
 import (
+	"fmt"
 	into_struct "github.com/wojnosystems/go-into-struct"
 	"github.com/wojnosystems/go-optional-parse-registry/v2"
 	"github.com/wojnosystems/go-parse-register"
-	"regexp"
+	"reflect"
 )
 
 // Env creates an environment parser given the provided registry
@@ -22,6 +23,10 @@ func NewWithParseRegistryEmitterEnvReader(parseRegistry parse_register.ValueSett
 			envReader:     reader,
 			parseRegistry: parseRegistry,
 			emitter:       emitter,
+			separators:    DefaultSeparators,
+			naming:        IdentityCase,
+			indexRegexp:   envIndexRegexp,
+			listSeparator: DefaultListSeparator,
 		},
 	}
 }
@@ -42,16 +47,114 @@ func NewWithParseRegistryWithEmitter(registry parse_register.ValueSetter, emitte
 	return NewWithParseRegistryEmitterEnvReader(registry, emitter, defaultEnvReader)
 }
 
+// WithPrefix namespaces every environment variable read by this Env with prefix.
+// For example, with the prefix "BILLING", the field Databases[0].Host is read from BILLING_Databases_0_Host.
+// Returns this Env to allow chaining with other configuration methods
+func (e *Env) WithPrefix(prefix string) *Env {
+	e.config.prefix = prefix
+	return e
+}
+
+// WithSeparators changes the separators placed between the parts of environment variable names.
+// For example, with Separators{Field: "__", IndexStart: "__", IndexEnd: "__"} and the prefix "APP",
+// the field Databases[0].Host is read from APP__Databases__0__Host. The default is DefaultSeparators.
+// Returns this Env to allow chaining with other configuration methods
+func (e *Env) WithSeparators(separators Separators) *Env {
+	e.config.separators = separators
+	e.config.indexRegexp = separators.indexRegexp()
+	return e
+}
+
+// WithNamingStrategy changes how the names of fields without env tags are converted into environment variable names.
+// For example, with ScreamingSnakeCase, the field ConnTimeout is read from CONN_TIMEOUT. The default is IdentityCase.
+// Returns this Env to allow chaining with other configuration methods
+func (e *Env) WithNamingStrategy(strategy NamingStrategy) *Env {
+	e.config.naming = strategy
+	return e
+}
+
+// WithCaseInsensitiveNames ignores the case of environment variable names, so databases_0_host and DATABASES_0_HOST
+// both set the field named Databases_0_Host. If several variables differ only by case, Unmarshall returns a ParseError
+// instead of picking one of them. Works with any EnvReader, but reads all of its keys for every lookup.
+// Returns this Env to allow chaining with other configuration methods
+func (e *Env) WithCaseInsensitiveNames() *Env {
+	e.config.caseInsensitive = true
+	return e
+}
+
+// WithInlineEmbedded names the fields of embedded structures as if they were fields of the structure embedding them.
+// For example, the CertFile field of a TLSConfig embedded in HTTP is read from HTTP_CertFile instead of
+// HTTP_TLSConfig_CertFile. Embedded structures with an env tag name keep their name. Individual fields can be inlined
+// with the inline tag option instead: `env:",inline"`. Structures of unexported types are skipped, even when embedded,
+// so their promoted fields are not read.
+// Returns this Env to allow chaining with other configuration methods
+func (e *Env) WithInlineEmbedded() *Env {
+	e.config.inlineEmbedded = true
+	return e
+}
+
+// WithFileSuffix allows values to be read from files, which is how Docker and Swarm provide secrets.
+// If a variable, such as DB_PASSWORD, is not set, but DB_PASSWORD followed by suffix is, for example,
+// DB_PASSWORD_FILE=/run/secrets/db_password, then the contents of that file, without trailing newlines,
+// are used as the value. The value of the variable itself always takes precedence over the file.
+// Values read from files are never passed to the SetReceiver or included in errors, the name of the file is reported instead.
+// Use DefaultFileSuffix for the common "_FILE" convention. Blank disables reading from files, which is the default
+func (e *Env) WithFileSuffix(suffix string) *Env {
+	e.config.fileSuffix = suffix
+	return e
+}
+
+// WithListSeparator changes the separator between the elements of slices set with a single variable, such as
+// ALLOWED_ORIGINS=a.com,b.com. Fields with the sep tag option use their own separator. The default is DefaultListSeparator.
+// Blank disables lists, except for fields with the sep tag option.
+// Returns this Env to allow chaining with other configuration methods
+func (e *Env) WithListSeparator(separator string) *Env {
+	e.config.listSeparator = separator
+	return e
+}
+
 // Unmarshall reads the environment variables and writes them to into.
 // into should be a reference to a struct
 // This method will do some basic checks on the into value, but to help developers pass in the correct values
 func (e *Env) Unmarshall(into interface{}) (err error) {
+	intoT := reflect.TypeOf(into)
+	if intoT != nil && intoT.Kind() == reflect.Ptr && intoT.Elem().Kind() == reflect.Struct {
+		err = e.Check(intoT)
+		if err != nil {
+			return
+		}
+	}
 	return into_struct.Unmarshall(into, &e.config)
 }
 
+// Check reports mistakes in the structure type t, or a pointer to one, without reading any environment variables:
+// invalid env tags, and fields that could read the same environment variable, which are reported as a
+// NameCollisionError. Unmarshall performs the same checks, this allows them to run in tests instead
+func (e *Env) Check(t reflect.Type) error {
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return fmt.Errorf("only structure types can be checked, not %v", t)
+	}
+	return e.config.checkCollisions(t)
+}
+
+// Check reports mistakes in the structure type t using the default configuration. See Env.Check
+func Check(t reflect.Type) error {
+	return New().Check(t)
+}
+
+// DefaultListSeparator separates the elements of slices set with a single variable
+const DefaultListSeparator = ","
+
+// DefaultFileSuffix is the suffix used by the official Docker images to indicate a variable contains the name of a
+// file that holds the value, rather than the value itself
+const DefaultFileSuffix = "_FILE"
+
 var (
 	defaultEnvReader       = &OsEnv{}
 	defaultNoOpSetReceiver = &SetReceiverNoOp{}
-	defaultParseRegister   = optional_parse_registry.NewWithGoPrimitives()
-	envIndexRegexp         = regexp.MustCompile(`^(\d+)`)
+	defaultParseRegister   = RegisterNetworkTypes(optional_parse_registry.NewWithGoPrimitives())
+	envIndexRegexp         = DefaultSeparators.indexRegexp()
 )
@@ -1,9 +1,13 @@
 package v2
 
 import (
-	"fmt"
+	"encoding"
+	"errors"
 	into_struct "github.com/wojnosystems/go-into-struct"
 	parse_register "github.com/wojnosystems/go-parse-register"
+	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -16,6 +20,33 @@ type envInternal struct {
 	// ParseRegistry maps go-default and custom types to members of the provided structure. If left blank, defaults to just Go's primitives being mapped
 	parseRegistry parse_register.ValueSetter
 	emitter       SetReceiver
+	// prefix is prepended to every environment variable name, followed by the field separator. Blank for no prefix
+	prefix string
+	// separators are placed between the parts of environment variable names
+	separators Separators
+	// naming converts the names of fields without env tags into environment variable names
+	naming NamingStrategy
+	// indexRegexp finds the slice indexes in environment variable names, it must match the separators
+	indexRegexp *regexp.Regexp
+	// fileSuffix, when not blank, allows a variable to be read from the file named by the variable with this suffix
+	// when the variable itself is not set. Blank to disable
+	fileSuffix string
+	// caseInsensitive ignores the case of environment variable names when true
+	caseInsensitive bool
+	// inlineEmbedded adds the fields of untagged, embedded structures to the parent's namespace when true
+	inlineEmbedded bool
+	// roots, when set, replace the prefix as the start of every name. Used to fill map elements and the targets of pointers
+	roots []envNameCandidate
+	// structPathPrefix is the path to the structure being filled, when it is an element of a map or the target of a pointer.
+	// Only used in errors
+	structPathPrefix string
+	// inElement is true when the structure being filled is within a slice or map element, where absolute names are not allowed
+	inElement bool
+	// listSeparator splits a single variable into the elements of a slice, unless the field has a sep tag option
+	listSeparator string
+	// elementSliceTag, when set, is the tag of the only field of the structure being filled, which holds a slice nested in
+	// a slice. The field has no name of its own: its names are the roots. See setNestedSlice
+	elementSliceTag *envTag
 }
 
 // SetValue
@@ -24,68 +55,391 @@ func (e *envInternal) SetValue(structFullPath into_struct.Path) (handled bool, e
 	if field == nil {
 		return
 	}
-	envPath := structToEnvPath(structFullPath)
-	envValue := e.envReader.Get(envPath)
+	tag, err := e.fieldTag(structFullPath.Parts())
+	if err != nil {
+		return
+	}
+	if isSkipped(field.StructField(), tag) {
+		// claim the field as handled, so nothing tries to fill it in
+		handled = true
+		return
+	}
+	element, isElement := field.(into_struct.PathSliceParter)
+	if isElement {
+		tag = tag.forElement()
+	}
+	candidates, err := e.structToEnvPaths(structFullPath)
+	if err != nil {
+		return
+	}
+	if isElement {
+		var values sliceValues
+		values, err = e.readSlice(structFullPath.Parts(), field.Type())
+		if err != nil {
+			return
+		}
+		if values.items != nil {
+			return e.setListElement(structFullPath, values, element.Index())
+		}
+	}
+	if e.isStructMap(field.Type()) {
+		handled = true
+		err = e.setStructMap(structFullPath, candidates, tag)
+		return
+	}
+	if e.isScalarMap(field.Type()) {
+		handled = true
+		err = e.setScalarMap(structFullPath, candidates, tag)
+		return
+	}
+	if field.Type().Kind() == reflect.Ptr {
+		return e.setPointer(structFullPath, candidates, tag)
+	}
+	envValue, source, err := e.fieldValue(structFullPath.Parts(), candidates, tag)
+	if err != nil {
+		return
+	}
+	valueDst := field.Value().Addr().Interface()
 	if "" != envValue {
 		// Some environment value was set, use it
-		valueDst := field.Value().Addr().Interface()
-		handled, err = e.parseRegistry.SetValue(valueDst, envValue)
-		if err != nil {
-			err = newParseError(structFullPath.String(), envPath, err)
+		handled, err = e.parseValue(structFullPath, candidates[0].name, envValue, source, valueDst)
+		if err != nil || handled {
 			return
 		}
-		if handled {
-			e.emitter.ReceiveSet(structFullPath, envPath, envValue)
+	}
+	if tag.required && !e.isConfigured(candidates, valueDst) {
+		err = newParseError(e.structPathString(structFullPath.Parts()), candidates[0].name, ErrRequired)
+		return
+	}
+	// values that are not set are left as they are, instead of into_struct looking for fields inside of them
+	handled = e.isParseable(valueDst)
+	return
+}
+
+// fieldValue reads the value of the field at parts from the first of the candidates that is set,
+// or the default from its tag. The value is blank if neither is set
+func (e *envInternal) fieldValue(parts []into_struct.PathParter, candidates []envNameCandidate, tag envTag) (envValue string, source envValueSource, err error) {
+	envValue, source, err = e.lookupCandidates(candidates)
+	if err != nil {
+		err = newParseError(e.structPathString(parts), source.envName, err)
+		return
+	}
+	source.secret = tag.secret
+	source.network = tag.network
+	if "" == envValue && tag.hasDefault {
+		envValue = tag.defaultValue
+		source.isDefault = true
+	}
+	return
+}
+
+// parseValue parses envValue into valueDst, and tells the SetReceiver about it. envPath is the preferred name of the field.
+// Types the registry does not support are parsed with UnmarshalText, if they implement encoding.TextUnmarshaler.
+// handled is false if neither can parse valueDst
+func (e *envInternal) parseValue(structFullPath into_struct.Path, envPath string, envValue string, source envValueSource, valueDst interface{}) (handled bool, err error) {
+	handled, err = e.unmarshalValue(e.structPathString(structFullPath.Parts()), envValue, source, valueDst)
+	if err == nil && handled {
+		e.receive(structFullPath, envPath, source, source.reportedValue(envValue))
+	}
+	return
+}
+
+// unmarshalValue parses envValue into valueDst without telling the SetReceiver. structPath is only used in errors
+func (e *envInternal) unmarshalValue(structPath string, envValue string, source envValueSource, valueDst interface{}) (handled bool, err error) {
+	source.network.prepare(valueDst)
+	handled, err = e.parseRegistry.SetValue(valueDst, envValue)
+	if !handled && err == nil {
+		if unmarshaler, ok := valueDst.(encoding.TextUnmarshaler); ok {
+			handled, err = true, unmarshaler.UnmarshalText([]byte(envValue))
+		}
+	}
+	if handled && err == nil {
+		err = source.network.check(valueDst)
+	}
+	if err != nil {
+		err = newParseError(structPath, source.envName, source.redactError(err, valueDst))
+	}
+	return
+}
+
+// receive tells the SetReceiver that reportedValue was set from source, unless it is a default.
+// envPath is the preferred name of the field, passed to a DeprecationReceiver if source is a deprecated name
+func (e *envInternal) receive(structFullPath into_struct.Path, envPath string, source envValueSource, reportedValue string) {
+	if !source.isDefault {
+		e.emitter.ReceiveSet(structFullPath, source.envName, reportedValue)
+	}
+	if receiver, ok := e.emitter.(DeprecationReceiver); ok && source.deprecated {
+		receiver.ReceiveDeprecated(structFullPath, source.envName, envPath)
+	}
+}
+
+// isParseable is true when values can be parsed into valueDst, a pointer, as a whole: it is supported by the registry,
+// or implements encoding.TextUnmarshaler
+func (e *envInternal) isParseable(valueDst interface{}) bool {
+	if e.parseRegistry.IsSupported(valueDst) {
+		return true
+	}
+	_, ok := valueDst.(encoding.TextUnmarshaler)
+	return ok
+}
+
+// isConfigured is true when values can be parsed into valueDst and a value is set, or, for structures, when any variable
+// exists for one of its fields under any of the names of the structure
+func (e *envInternal) isConfigured(candidates []envNameCandidate, valueDst interface{}) bool {
+	if e.isParseable(valueDst) {
+		return false
+	}
+	return e.hasVariablesUnder(candidates)
+}
+
+// hasVariablesUnder is true when any variable is named after a field of a structure named by one of the candidates
+func (e *envInternal) hasVariablesUnder(candidates []envNameCandidate) bool {
+	for _, candidate := range candidates {
+		if len(e.keys(e.separators.appendField(candidate.envName, "").name)) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupCandidates reads the value of the first candidate that is set, trying them in order of preference
+func (e *envInternal) lookupCandidates(candidates []envNameCandidate) (value string, source envValueSource, err error) {
+	for _, candidate := range candidates {
+		value, source, err = e.lookupValue(candidate.name)
+		if err != nil || value != "" {
+			source.deprecated = candidate.deprecated
 			return
 		}
 	}
+	source = envValueSource{envName: candidates[0].name}
+	return
+}
+
+// lookupValue reads the value of the variable envPath. If it is not set and a fileSuffix is configured,
+// the value is read from the file named by the envPath+fileSuffix variable instead
+func (e *envInternal) lookupValue(envPath string) (value string, source envValueSource, err error) {
+	source.envName, value, err = e.getEnv(envPath)
+	if err != nil || value != "" || e.fileSuffix == "" {
+		return
+	}
+	fileEnvName, fileName, err := e.getEnv(envPath + e.fileSuffix)
+	if err != nil {
+		source.envName = fileEnvName
+		return
+	}
+	if fileName == "" {
+		return
+	}
+	source = envValueSource{
+		envName:  fileEnvName,
+		fileName: fileName,
+	}
+	value, err = readValueFile(fileName)
 	return
 }
 
 func (e *envInternal) SliceLen(structFullPath into_struct.Path) (length int, err error) {
-	envPath := structToEnvPath(structFullPath)
-	pathPrefix := envPath + "_"
+	tag, err := e.fieldTag(structFullPath.Parts())
+	if err != nil {
+		return
+	}
+	if isSkipped(structFullPath.Top().StructField(), tag) {
+		return
+	}
+	if e.isParseable(structFullPath.Top().Value().Addr().Interface()) {
+		// into_struct fills slices one element at a time, but slices such as net.IP are parsed as a whole
+		_, err = e.SetValue(structFullPath)
+		return
+	}
+	if _, isElement := structFullPath.Top().(into_struct.PathSliceParter); isElement {
+		// a slice nested in a slice, such as an element of a [][]int
+		err = e.setNestedSlice(structFullPath)
+		return
+	}
+	values, err := e.readSlice(structFullPath.Parts(), structFullPath.Top().Type().Elem())
+	if err != nil {
+		return
+	}
+	length = values.length
+	if length == 0 && tag.required {
+		err = newParseError(e.structPathString(structFullPath.Parts()), values.envPath, ErrRequired)
+	}
+	return
+}
+
+// indexedLength is the length of the slice named by the candidates, as set by variables with indexes
+func (e *envInternal) indexedLength(parts []into_struct.PathParter, candidates []envNameCandidate) (length int, err error) {
+	// elements may be set using any of the names of the slice, so the slice is long enough for all of them
 	maxIndex := int64(-1)
-	for _, key := range e.envReader.Keys(pathPrefix) {
-		possibleNumber := envIndexRegexp.FindString(key[len(pathPrefix):])
-		if "" != possibleNumber {
-			var index int64
-			index, err = strconv.ParseInt(possibleNumber, 10, 0)
-			if err != nil {
-				err = newParseError(structFullPath.String(), envPath, err)
-				return
+	for _, candidate := range candidates {
+		pathPrefix := e.separators.indexPrefix(candidate.envName)
+		for _, key := range e.keys(pathPrefix) {
+			if match := e.indexRegexp.FindStringSubmatch(key[len(pathPrefix):]); match != nil {
+				possibleNumber := match[1]
+				var index int64
+				index, err = strconv.ParseInt(possibleNumber, 10, 0)
+				if err != nil {
+					err = newParseError(e.structPathString(parts), candidate.name, err)
+					return
+				}
+				if index > maxIndex {
+					maxIndex = index
+				}
 			}
-			if index > maxIndex {
-				maxIndex = index
+		}
+	}
+	return int(maxIndex + 1), nil
+}
+
+// structToEnvPaths converts the path to a field in the destination structure into the names of the environment variables
+// that can hold its value, including the prefix, if one was configured. The first candidate is the preferred name,
+// followed by names using aliases, then names using deprecated names
+func (e *envInternal) structToEnvPaths(structPath into_struct.Path) (candidates []envNameCandidate, err error) {
+	return e.partsToEnvPaths(structPath.Parts(), true)
+}
+
+// partsToEnvPaths is structToEnvPaths for parts. When indexLast is false and the last part is a slice element,
+// the names are those of the slice, rather than of the element
+func (e *envInternal) partsToEnvPaths(parts []into_struct.PathParter, indexLast bool) (candidates []envNameCandidate, err error) {
+	candidates = e.rootEnvNames()
+	for i, pathPart := range parts {
+		var tag envTag
+		tag, err = e.fieldTag(parts[:i+1])
+		if err != nil {
+			return
+		}
+		if tag.absolute && (e.inElement || containsElement(parts[:i])) {
+			err = newTagError(e.structPathString(parts[:i+1]), pathPart.StructField().Tag.Get("env"), errAbsoluteInElement)
+			return
+		}
+		if i != 0 || e.elementSliceTag == nil {
+			candidates = e.appendFieldNames(candidates, pathPart.StructField(), tag)
+		}
+		if t, ok := pathPart.(into_struct.PathSliceParter); ok && (indexLast || i != len(parts)-1) {
+			for j := range candidates {
+				candidates[j].envName = e.separators.appendIndex(candidates[j].envName, t.Index())
 			}
 		}
 	}
-	length = int(maxIndex + 1)
 	return
 }
 
-const envFieldSeparator = "_"
+// errAbsoluteInElement is the reason absolute names are rejected inside slices and maps
+var errAbsoluteInElement = errors.New("option 'absolute' cannot be used inside slices or maps, as every element would read the same variable")
+
+// containsElement is true when any of parts is an element of a slice
+func containsElement(parts []into_struct.PathParter) bool {
+	for _, part := range parts {
+		if _, ok := part.(into_struct.PathSliceParter); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isSkipped is true for fields that are never read from the environment: those tagged with `env:"-"` and unexported fields,
+// which cannot be set from outside their package
+func isSkipped(field reflect.StructField, tag envTag) bool {
+	return tag.skip || field.PkgPath != ""
+}
 
-func structToEnvPath(structPath into_struct.Path) string {
-	envParts := make([]string, 0, len(structPath.Parts()))
-	for _, pathPart := range structPath.Parts() {
-		fieldEnvName := pathPart.StructField().Tag.Get("env")
-		if fieldEnvName == "" {
-			fieldEnvName = pathPart.StructField().Name
+// rootEnvNames are where the names of all variables start: the prefix, if there is one,
+// or the names of the map element or pointer target being filled
+func (e *envInternal) rootEnvNames() []envNameCandidate {
+	if e.roots != nil {
+		return append([]envNameCandidate{}, e.roots...)
+	}
+	if e.prefix == "" {
+		return []envNameCandidate{{envName: rootEnvName}}
+	}
+	return []envNameCandidate{{envName: e.separators.appendField(rootEnvName, e.prefix)}}
+}
+
+// envNameCandidate is one of the names of the variable a field can be read from
+type envNameCandidate struct {
+	envName
+	// deprecated is true when the name of the field, or of any structure containing it, is a deprecated name
+	deprecated bool
+}
+
+// appendFieldNames adds the names of field to each of the parents, unless field is inline.
+// Absolute names replace the parents entirely.
+// Names using the deprecated names of field are moved after all the others
+func (e *envInternal) appendFieldNames(parents []envNameCandidate, field reflect.StructField, tag envTag) (candidates []envNameCandidate) {
+	fieldPrefix := field.Tag.Get("envPrefix")
+	if tag.absolute {
+		parents, fieldPrefix = []envNameCandidate{{envName: rootEnvName}}, ""
+	}
+	inline := e.isInline(field, tag)
+	fieldEnvName := tag.name
+	if fieldEnvName == "" {
+		fieldEnvName = e.naming(field.Name)
+	}
+	names := append([]string{fieldEnvName}, tag.aliases...)
+	names = append(names, tag.deprecated...)
+	candidates = make([]envNameCandidate, 0, len(parents)*len(names))
+	for _, parent := range parents {
+		name := parent.envName
+		if fieldPrefix != "" {
+			name = e.separators.appendField(name, fieldPrefix)
 		}
-		switch t := pathPart.(type) {
-		case into_struct.PathSliceParter:
-			envParts = append(envParts, fmt.Sprintf("%s%s%d%s", fieldEnvName, envFieldSeparator, t.Index(), envFieldSeparator))
-		default:
-			envParts = append(envParts, fieldEnvName)
+		if inline {
+			candidates = append(candidates, envNameCandidate{envName: name, deprecated: parent.deprecated})
+			continue
 		}
+		for i, fieldName := range names {
+			candidates = append(candidates, envNameCandidate{
+				envName:    e.separators.appendField(name, fieldName),
+				deprecated: parent.deprecated || i > len(tag.aliases),
+			})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return !candidates[i].deprecated && candidates[j].deprecated
+	})
+	return
+}
+
+// isInline is true when the fields of field should be named as if they were fields of the structure containing field.
+// Embedded structures are only inline if inlineEmbedded is enabled and the structure is not a value, such as optional.String
+func (e *envInternal) isInline(field reflect.StructField, tag envTag) bool {
+	if tag.inline {
+		return true
+	}
+	return e.inlineEmbedded && field.Anonymous && tag.name == "" &&
+		field.Type.Kind() == reflect.Struct && !e.isParseable(reflect.New(field.Type).Interface())
+}
+
+// fieldTag parses the env tag of the last part in parts. Errors include the path to the field
+func (e *envInternal) fieldTag(parts []into_struct.PathParter) (tag envTag, err error) {
+	if len(parts) == 0 {
+		return
+	}
+	if len(parts) == 1 && e.elementSliceTag != nil {
+		return *e.elementSliceTag, nil
+	}
+	field := parts[len(parts)-1].StructField()
+	tag, err = parseEnvTag(field)
+	if err != nil {
+		err = newTagError(e.structPathString(parts), field.Tag.Get("env"), err)
+	}
+	return
+}
+
+// structPathString formats parts the same way as into_struct.Path.String, starting with the path to the map element
+// being filled, if any
+func (e *envInternal) structPathString(parts []into_struct.PathParter) string {
+	stringParts := make([]string, 0, len(parts)+1)
+	if e.structPathPrefix != "" {
+		stringParts = append(stringParts, e.structPathPrefix)
 	}
-	sb := strings.Builder{}
-	for i, part := range envParts {
-		sb.WriteString(part)
-		if i < len(envParts)-1 && !strings.HasSuffix(part, envFieldSeparator) {
-			sb.WriteString(envFieldSeparator)
+	for i, part := range parts {
+		if i == 0 && e.elementSliceTag != nil {
+			// the field holding a nested slice has no name of its own, only the indexes of its elements: Matrix[0][1]
+			stringParts[0] += strings.TrimPrefix(part.String(), part.Name())
+			continue
 		}
+		stringParts = append(stringParts, part.String())
 	}
-	return sb.String()
+	return strings.Join(stringParts, ".")
 }
@@ -0,0 +1,52 @@
+package v2
+
+import (
+	"fmt"
+	"strings"
+)
+
+// getEnv reads the variable envNamed from the envReader. key is the name of the variable that was actually read,
+// which differs from envNamed in case when caseInsensitive is enabled
+func (e *envInternal) getEnv(envNamed string) (key string, value string, err error) {
+	if !e.caseInsensitive {
+		return envNamed, e.envReader.Get(envNamed), nil
+	}
+	var matches []string
+	for _, candidate := range e.envReader.Keys("") {
+		if strings.EqualFold(candidate, envNamed) {
+			matches = append(matches, candidate)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return envNamed, "", nil
+	case 1:
+		return matches[0], e.envReader.Get(matches[0]), nil
+	default:
+		return envNamed, "", &caseConflictError{keys: matches}
+	}
+}
+
+// keys lists the variables in the envReader that begin with prefix, ignoring case when caseInsensitive is enabled.
+// The keys are returned as named in the envReader, but the matching prefix is always the same length as prefix
+func (e *envInternal) keys(prefix string) (out []string) {
+	if !e.caseInsensitive {
+		return e.envReader.Keys(prefix)
+	}
+	out = []string{}
+	for _, key := range e.envReader.Keys("") {
+		if len(key) >= len(prefix) && strings.EqualFold(key[:len(prefix)], prefix) {
+			out = append(out, key)
+		}
+	}
+	return
+}
+
+// caseConflictError is returned when matching is case-insensitive, but several variables differ only by case
+type caseConflictError struct {
+	keys []string
+}
+
+func (c *caseConflictError) Error() string {
+	return fmt.Sprintf("'%s' differ only by case, set only one of them", strings.Join(c.keys, "', '"))
+}
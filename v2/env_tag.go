@@ -0,0 +1,186 @@
+package v2
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// envTag is the parsed form of the env struct tag. The first item is the name, followed by comma-separated options:
+//
+//	`env:"PORT,required,default=8080,secret,sep=;"`
+//
+// Other names for the field are listed with alias= and deprecated=, which may be repeated:
+//
+//	`env:"DATABASE_HOST,alias=DB_SERVER,deprecated=DB_HOST"`
+//
+// URLs can be limited to some schemes, and endpoints given a default port:
+//
+//	`env:"ENDPOINT,schemes=http|https"` `env:"REDIS,port=6379"`
+//
+// Commas and backslashes in option values are escaped with a backslash: `env:"HOSTS,sep=\\,"`
+//
+// The tag `env:"-"` skips the field entirely. To name a field "-", use `env:"-,"`
+type envTag struct {
+	// skip fields are never read from the environment
+	skip bool
+	// name replaces the name of the field. Blank to name the field using the NamingStrategy
+	name string
+	// required fields must be set, or Unmarshall fails
+	required bool
+	// defaultValue is used when the variable is not set, if hasDefault is true
+	defaultValue string
+	hasDefault   bool
+	// secret values are never passed to the SetReceiver or included in errors
+	secret bool
+	// separator splits delimited values into elements. Blank to use the default
+	separator string
+	// inline structures add their fields to the parent's namespace instead of adding a name of their own
+	inline bool
+	// aliases are other names the field is read from when it is not set using name, in order of preference
+	aliases []string
+	// deprecated names are read after the aliases. Values read from them are reported to a DeprecationReceiver
+	deprecated []string
+	// absolute names are used as they are, without the names of the structures containing the field or the prefix
+	absolute bool
+	// network validates url.URL values and sets the default port of HostPort values
+	network networkOptions
+}
+
+// parseEnvTag reads the env tag of field
+func parseEnvTag(field reflect.StructField) (tag envTag, err error) {
+	if field.Tag.Get("env") == "-" {
+		tag.skip = true
+		return
+	}
+	items := splitEnvTag(field.Tag.Get("env"))
+	tag.name = items[0]
+	seen := make(map[string]bool, len(items)-1)
+	for _, item := range items[1:] {
+		if item == "" {
+			continue
+		}
+		option, value := item, ""
+		hasValue := false
+		if equals := strings.IndexByte(item, '='); equals != -1 {
+			option, value, hasValue = item[:equals], item[equals+1:], true
+		}
+		if seen[option] && option != "alias" && option != "deprecated" {
+			err = fmt.Errorf("option '%s' is repeated", option)
+			return
+		}
+		seen[option] = true
+		switch option {
+		case "required":
+			tag.required = true
+		case "default":
+			tag.defaultValue, tag.hasDefault = value, true
+		case "secret":
+			tag.secret = true
+		case "absolute":
+			tag.absolute = true
+		case "inline":
+			if field.Type.Kind() != reflect.Struct {
+				err = fmt.Errorf("option 'inline' can only be used on struct fields")
+				return
+			}
+			tag.inline = true
+		case "sep":
+			if value == "" {
+				err = fmt.Errorf("option 'sep' requires a value, such as sep=;")
+				return
+			}
+			if kind := field.Type.Kind(); kind != reflect.Slice && kind != reflect.Map {
+				err = fmt.Errorf("option 'sep' can only be used on slice and map fields")
+				return
+			}
+			tag.separator = value
+		case "schemes":
+			if value == "" {
+				err = fmt.Errorf("option 'schemes' requires a value, such as schemes=http|https")
+				return
+			}
+			if valueType(field.Type) != urlType {
+				err = fmt.Errorf("option 'schemes' can only be used on url.URL fields")
+				return
+			}
+			tag.network.schemes = strings.Split(value, "|")
+		case "port":
+			if valueType(field.Type) != hostPortType {
+				err = fmt.Errorf("option 'port' can only be used on HostPort fields")
+				return
+			}
+			var port uint64
+			port, err = strconv.ParseUint(value, 10, 16)
+			if err != nil || port == 0 {
+				err = fmt.Errorf("option 'port' requires a port number, such as port=443")
+				return
+			}
+			tag.network.defaultPort = uint16(port)
+		case "alias", "deprecated":
+			if value == "" {
+				err = fmt.Errorf("option '%s' requires a value, such as %s=OLD_NAME", option, option)
+				return
+			}
+			if option == "alias" {
+				tag.aliases = append(tag.aliases, value)
+			} else {
+				tag.deprecated = append(tag.deprecated, value)
+			}
+		default:
+			err = fmt.Errorf("unknown option '%s'", option)
+			return
+		}
+		if hasValue != tagOptionHasValue(option) {
+			err = fmt.Errorf("option '%s' is malformed", item)
+			return
+		}
+	}
+	if tag.inline && (tag.name != "" || len(tag.aliases) != 0 || len(tag.deprecated) != 0) {
+		err = fmt.Errorf("inline fields cannot have a name")
+		return
+	}
+	if tag.absolute && tag.inline {
+		err = fmt.Errorf("options 'absolute' and 'inline' cannot be used together")
+		return
+	}
+	if tag.required && tag.hasDefault {
+		err = fmt.Errorf("options 'required' and 'default' cannot be used together")
+	}
+	return
+}
+
+// tagOptionHasValue is true for options written as option=value
+func tagOptionHasValue(option string) bool {
+	switch option {
+	case "default", "sep", "alias", "deprecated", "schemes", "port":
+		return true
+	}
+	return false
+}
+
+// forElement returns the options that apply to each element of a slice, rather than to the slice as a whole
+func (t envTag) forElement() envTag {
+	t.required = false
+	t.defaultValue, t.hasDefault = "", false
+	return t
+}
+
+// splitEnvTag splits a tag on commas that are not escaped with a backslash
+func splitEnvTag(tag string) (items []string) {
+	sb := strings.Builder{}
+	for i := 0; i < len(tag); i++ {
+		switch {
+		case tag[i] == '\\' && i+1 < len(tag) && (tag[i+1] == ',' || tag[i+1] == '\\'):
+			i++
+			sb.WriteByte(tag[i])
+		case tag[i] == ',':
+			items = append(items, sb.String())
+			sb.Reset()
+		default:
+			sb.WriteByte(tag[i])
+		}
+	}
+	return append(items, sb.String())
+}
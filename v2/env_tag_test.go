@@ -0,0 +1,141 @@
+package v2
+
+import (
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+)
+
+func TestParseEnvTag(t *testing.T) {
+	cases := map[string]struct {
+		tag       reflect.StructTag
+		fieldType reflect.Type
+		expected  envTag
+	}{
+		"no tag": {},
+		"name only": {
+			tag:      `env:"PORT"`,
+			expected: envTag{name: "PORT"},
+		},
+		"options without name": {
+			tag:      `env:",required"`,
+			expected: envTag{required: true},
+		},
+		"all options": {
+			tag:       `env:"PORTS,default=8080,secret,sep=;"`,
+			fieldType: reflect.TypeOf([]int{}),
+			expected:  envTag{name: "PORTS", defaultValue: "8080", hasDefault: true, secret: true, separator: ";"},
+		},
+		"empty default": {
+			tag:      `env:"PORT,default="`,
+			expected: envTag{name: "PORT", hasDefault: true},
+		},
+		"escaped commas": {
+			tag:       `env:"HOSTS,sep=\\,,default=a\\,b\\\\c"`,
+			fieldType: reflect.TypeOf([]string{}),
+			expected:  envTag{name: "HOSTS", separator: ",", defaultValue: `a,b\c`, hasDefault: true},
+		},
+		"separator on a map": {
+			tag:       `env:"LABELS,sep=;"`,
+			fieldType: reflect.TypeOf(map[string]string{}),
+			expected:  envTag{name: "LABELS", separator: ";"},
+		},
+		"skip": {
+			tag:      `env:"-"`,
+			expected: envTag{skip: true},
+		},
+		"named dash": {
+			tag:      `env:"-,"`,
+			expected: envTag{name: "-"},
+		},
+		"equals in default": {
+			tag:      `env:"LABELS,default=a=b"`,
+			expected: envTag{name: "LABELS", defaultValue: "a=b", hasDefault: true},
+		},
+		"absolute": {
+			tag:      `env:"PORT,absolute"`,
+			expected: envTag{name: "PORT", absolute: true},
+		},
+		"aliases and deprecated names": {
+			tag:      `env:"DATABASE_HOST,deprecated=DB_HOST,alias=DB_SERVER,deprecated=HOST,alias=DBHOST"`,
+			expected: envTag{name: "DATABASE_HOST", aliases: []string{"DB_SERVER", "DBHOST"}, deprecated: []string{"DB_HOST", "HOST"}},
+		},
+	}
+
+	for caseName, c := range cases {
+		t.Run(caseName, func(t *testing.T) {
+			actual, err := parseEnvTag(reflect.StructField{Tag: c.tag, Type: c.fieldType})
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, actual)
+		})
+	}
+}
+
+func TestParseEnvTagErrors(t *testing.T) {
+	cases := map[string]struct {
+		tag       reflect.StructTag
+		fieldType reflect.Type
+		expected  string
+	}{
+		"unknown option": {
+			tag:      `env:"PORT,requried"`,
+			expected: "unknown option 'requried'",
+		},
+		"repeated option": {
+			tag:      `env:"PORT,secret,secret"`,
+			expected: "option 'secret' is repeated",
+		},
+		"flag with value": {
+			tag:      `env:"PORT,required=true"`,
+			expected: "option 'required=true' is malformed",
+		},
+		"default without value": {
+			tag:      `env:"PORT,default"`,
+			expected: "option 'default' is malformed",
+		},
+		"empty separator": {
+			tag:      `env:"PORT,sep="`,
+			expected: "option 'sep' requires a value, such as sep=;",
+		},
+		"required with default": {
+			tag:      `env:"PORT,required,default=1"`,
+			expected: "options 'required' and 'default' cannot be used together",
+		},
+		"absolute inline": {
+			tag:       `env:",absolute,inline"`,
+			fieldType: reflect.TypeOf(struct{}{}),
+			expected:  "options 'absolute' and 'inline' cannot be used together",
+		},
+		"alias without value": {
+			tag:      `env:"PORT,alias"`,
+			expected: "option 'alias' requires a value, such as alias=OLD_NAME",
+		},
+		"separator on string": {
+			tag:       `env:"HOST,sep=;"`,
+			fieldType: reflect.TypeOf(""),
+			expected:  "option 'sep' can only be used on slice and map fields",
+		},
+		"schemes on string": {
+			tag:       `env:"ENDPOINT,schemes=https"`,
+			fieldType: reflect.TypeOf(""),
+			expected:  "option 'schemes' can only be used on url.URL fields",
+		},
+		"port on string": {
+			tag:       `env:"REDIS,port=6379"`,
+			fieldType: reflect.TypeOf(""),
+			expected:  "option 'port' can only be used on HostPort fields",
+		},
+		"port out of range": {
+			tag:       `env:"REDIS,port=70000"`,
+			fieldType: reflect.TypeOf(HostPort{}),
+			expected:  "option 'port' requires a port number, such as port=443",
+		},
+	}
+
+	for caseName, c := range cases {
+		t.Run(caseName, func(t *testing.T) {
+			_, err := parseEnvTag(reflect.StructField{Tag: c.tag, Type: c.fieldType})
+			assert.EqualError(t, err, c.expected)
+		})
+	}
+}
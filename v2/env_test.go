@@ -1,8 +1,16 @@
 package v2
 
 import (
+	"errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/wojnosystems/go-optional/v2"
+	"io/ioutil"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -85,7 +93,7 @@ func TestEnv_UnmarshallErrors(t *testing.T) {
 					"Databases_0_NEST_TIMEOUT": "P30s",
 				},
 			},
-			expected: `environment variable 'Databases_0_NEST_TIMEOUT' failed to parse because time: invalid duration P30s`,
+			expected: `environment variable 'Databases_0_NEST_TIMEOUT' failed to parse because time: invalid duration "P30s"`,
 		},
 		"int field does not parse": {
 			env: &envMock{
@@ -117,3 +125,1325 @@ func TestEnv_UnmarshallErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestEnv_UnmarshallWithPrefix(t *testing.T) {
+	env := &envMock{
+		mock: map[string]string{
+			"Name":                             "ignored",
+			"BILLING_Name":                     "billing",
+			"BILLING_Databases_1_Host":         "example.com",
+			"BILLING_Databases_0_NEST_TIMEOUT": "30s",
+		},
+	}
+	receiver := &setReceiverMock{}
+	actual := &appConfigMock{}
+	err := NewWithParseRegistryEmitterEnvReader(defaultParseRegister, receiver, env).WithPrefix("BILLING").Unmarshall(actual)
+	assert.NoError(t, err)
+	assert.True(t, appConfigMock{
+		Name: optional.StringFrom("billing"),
+		Databases: []dbConfigMock{
+			{Nested: nestedDbConfigMock{ConnTimeout: optional.DurationFrom(30 * time.Second)}},
+			{Host: optional.StringFrom("example.com")},
+		},
+	}.IsEqual(actual))
+	assert.ElementsMatch(t, []setReceiverMockCall{
+		{StructPath: "Name", EnvName: "BILLING_Name", Value: "billing"},
+		{StructPath: "Databases[0].Nested.ConnTimeout", EnvName: "BILLING_Databases_0_NEST_TIMEOUT", Value: "30s"},
+		{StructPath: "Databases[1].Host", EnvName: "BILLING_Databases_1_Host", Value: "example.com"},
+	}, receiver.calls)
+}
+
+func TestEnv_UnmarshallWithPrefixError(t *testing.T) {
+	env := &envMock{
+		mock: map[string]string{
+			"BILLING_ThreadCount": "P",
+		},
+	}
+	err := NewWithEnvReader(env).WithPrefix("BILLING").Unmarshall(&appConfigMock{})
+	if assert.IsType(t, &ParseError{}, err) {
+		assert.Equal(t, StructEnvPath{StructPath: "ThreadCount", EnvPath: "BILLING_ThreadCount"}, err.(*ParseError).Path)
+	}
+}
+
+func TestEnv_UnmarshallWithFieldPrefix(t *testing.T) {
+	env := &envMock{
+		mock: map[string]string{
+			"APP_PRIMARY_Primary_Host": "primary.example.com",
+			"APP_REPLICA_DB_Host":      "replica.example.com",
+		},
+	}
+	actual := &prefixedConfigMock{}
+	err := NewWithEnvReader(env).WithPrefix("APP_").Unmarshall(actual)
+	assert.NoError(t, err)
+	assert.Equal(t, optional.StringFrom("primary.example.com"), actual.Primary.Host)
+	assert.Equal(t, optional.StringFrom("replica.example.com"), actual.Replica.Host)
+}
+
+func TestEnv_UnmarshallWithFileSuffix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	passwordFile := filepath.Join(dir, "db_password")
+	threadsFile := filepath.Join(dir, "threads")
+	assert.NoError(t, ioutil.WriteFile(passwordFile, []byte("s3cr3t\n"), 0600))
+	assert.NoError(t, ioutil.WriteFile(threadsFile, []byte("not-a-number\n"), 0600))
+
+	t.Run("reads file when variable not set", func(t *testing.T) {
+		receiver := &setReceiverMock{}
+		actual := &appConfigMock{}
+		env := &envMock{mock: map[string]string{
+			"Databases_0_Password_FILE": passwordFile,
+			"Databases_0_User":          "admin",
+			"Databases_0_User_FILE":     passwordFile,
+		}}
+		err := NewWithParseRegistryEmitterEnvReader(defaultParseRegister, receiver, env).WithFileSuffix(DefaultFileSuffix).Unmarshall(actual)
+		assert.NoError(t, err)
+		assert.Equal(t, optional.StringFrom("s3cr3t"), actual.Databases[0].Password)
+		assert.Equal(t, optional.StringFrom("admin"), actual.Databases[0].User)
+		assert.ElementsMatch(t, []setReceiverMockCall{
+			{StructPath: "Databases[0].User", EnvName: "Databases_0_User", Value: "admin"},
+			{StructPath: "Databases[0].Password", EnvName: "Databases_0_Password_FILE", Value: passwordFile},
+		}, receiver.calls)
+	})
+	t.Run("disabled by default", func(t *testing.T) {
+		actual := &appConfigMock{}
+		env := &envMock{mock: map[string]string{"Name_FILE": passwordFile}}
+		err := NewWithEnvReader(env).Unmarshall(actual)
+		assert.NoError(t, err)
+		assert.False(t, actual.Name.IsSet())
+	})
+	t.Run("parse error does not reveal contents", func(t *testing.T) {
+		env := &envMock{mock: map[string]string{"ThreadCount_FILE": threadsFile}}
+		err := NewWithEnvReader(env).WithFileSuffix(DefaultFileSuffix).Unmarshall(&appConfigMock{})
+		assert.EqualError(t, err, "environment variable 'ThreadCount_FILE' failed to parse because the contents of file '"+threadsFile+"' are not a valid optional.Int")
+	})
+	t.Run("map keys", func(t *testing.T) {
+		receiver := &setReceiverMock{}
+		actual := &labelsConfigMock{}
+		env := &envMock{mock: map[string]string{
+			"LABELS_team_FILE": passwordFile,
+			"LABELS_tier":      "1",
+			"LABELS_tier_FILE": threadsFile,
+		}}
+		err := NewWithParseRegistryEmitterEnvReader(defaultParseRegister, receiver, env).WithFileSuffix(DefaultFileSuffix).Unmarshall(actual)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"team": "s3cr3t", "tier": "1"}, actual.Labels)
+		assert.ElementsMatch(t, []setReceiverMockCall{
+			{StructPath: "Labels", EnvName: "LABELS_team_FILE", Value: passwordFile},
+			{StructPath: "Labels", EnvName: "LABELS_tier", Value: "1"},
+		}, receiver.calls)
+	})
+	t.Run("missing file", func(t *testing.T) {
+		env := &envMock{mock: map[string]string{"Name_FILE": filepath.Join(dir, "missing")}}
+		err := NewWithEnvReader(env).WithFileSuffix(DefaultFileSuffix).Unmarshall(&appConfigMock{})
+		if assert.IsType(t, &ParseError{}, err) {
+			assert.Equal(t, "Name_FILE", err.(*ParseError).Path.EnvPath)
+		}
+	})
+}
+
+func TestEnv_UnmarshallWithSeparators(t *testing.T) {
+	cases := map[string]struct {
+		separators Separators
+		env        *envMock
+		expected   appConfigMock
+	}{
+		"double underscore": {
+			separators: Separators{Field: "__", IndexStart: "__", IndexEnd: "__"},
+			env: &envMock{
+				mock: map[string]string{
+					"APP__Name":                        "SuperServer",
+					"APP__Databases__1__Host":          "example.com",
+					"APP__Databases__0__NEST__TIMEOUT": "30s",
+					"APP_Databases_2_Host":             "ignored",
+				},
+			},
+			expected: appConfigMock{
+				Name: optional.StringFrom("SuperServer"),
+				Databases: []dbConfigMock{
+					{Nested: nestedDbConfigMock{ConnTimeout: optional.DurationFrom(30 * time.Second)}},
+					{Host: optional.StringFrom("example.com")},
+				},
+			},
+		},
+		"no index end": {
+			separators: Separators{Field: "_", IndexStart: "_"},
+			env: &envMock{
+				mock: map[string]string{
+					"APP_Databases_1_Host":         "example.com",
+					"APP_Databases_0_NEST_TIMEOUT": "30s",
+				},
+			},
+			expected: appConfigMock{
+				Databases: []dbConfigMock{
+					{Nested: nestedDbConfigMock{ConnTimeout: optional.DurationFrom(30 * time.Second)}},
+					{Host: optional.StringFrom("example.com")},
+				},
+			},
+		},
+		"brackets": {
+			separators: Separators{Field: ".", IndexStart: "[", IndexEnd: "]."},
+			env: &envMock{
+				mock: map[string]string{
+					"APP.Databases[1].Host":         "example.com",
+					"APP.Databases[0].NEST.TIMEOUT": "30s",
+				},
+			},
+			expected: appConfigMock{
+				Databases: []dbConfigMock{
+					{Nested: nestedDbConfigMock{ConnTimeout: optional.DurationFrom(30 * time.Second)}},
+					{Host: optional.StringFrom("example.com")},
+				},
+			},
+		},
+	}
+
+	for caseName, c := range cases {
+		t.Run(caseName, func(t *testing.T) {
+			actual := &appConfigMock{}
+			err := NewWithEnvReader(c.env).WithPrefix("APP").WithSeparators(c.separators).Unmarshall(actual)
+			assert.NoError(t, err)
+			assert.True(t, c.expected.IsEqual(actual))
+		})
+	}
+}
+
+func TestEnv_UnmarshallWithSeparatorsOsEnv(t *testing.T) {
+	t.Setenv("GOENVTEST_ORIGINS_0", "a.com")
+	t.Setenv("GOENVTEST_ORIGINS_1", "b.com")
+	actual := &listConfigMock{}
+	err := New().WithPrefix("GOENVTEST").WithSeparators(Separators{Field: "_", IndexStart: "_"}).Unmarshall(actual)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.com", "b.com"}, actual.Origins)
+}
+
+func TestEnv_UnmarshallWithNamingStrategy(t *testing.T) {
+	env := &envMock{
+		mock: map[string]string{
+			"NAME":                     "SuperServer",
+			"THREAD_COUNT":             "4",
+			"DATABASES_0_HOST":         "example.com",
+			"DATABASES_0_NEST_TIMEOUT": "30s",
+		},
+	}
+	actual := &appConfigMock{}
+	err := NewWithEnvReader(env).WithNamingStrategy(ScreamingSnakeCase).Unmarshall(actual)
+	assert.NoError(t, err)
+	assert.True(t, appConfigMock{
+		Name:        optional.StringFrom("SuperServer"),
+		ThreadCount: optional.IntFrom(4),
+		Databases: []dbConfigMock{
+			{
+				Host:   optional.StringFrom("example.com"),
+				Nested: nestedDbConfigMock{ConnTimeout: optional.DurationFrom(30 * time.Second)},
+			},
+		},
+	}.IsEqual(actual))
+}
+
+func TestEnv_UnmarshallWithCaseInsensitiveNames(t *testing.T) {
+	receiver := &setReceiverMock{}
+	env := &envMock{
+		mock: map[string]string{
+			"NAME":                     "SuperServer",
+			"databases_1_host":         "example.com",
+			"DATABASES_0_nest_timeout": "30s",
+		},
+	}
+	actual := &appConfigMock{}
+	err := NewWithParseRegistryEmitterEnvReader(defaultParseRegister, receiver, env).WithCaseInsensitiveNames().Unmarshall(actual)
+	assert.NoError(t, err)
+	assert.True(t, appConfigMock{
+		Name: optional.StringFrom("SuperServer"),
+		Databases: []dbConfigMock{
+			{Nested: nestedDbConfigMock{ConnTimeout: optional.DurationFrom(30 * time.Second)}},
+			{Host: optional.StringFrom("example.com")},
+		},
+	}.IsEqual(actual))
+	assert.ElementsMatch(t, []setReceiverMockCall{
+		{StructPath: "Name", EnvName: "NAME", Value: "SuperServer"},
+		{StructPath: "Databases[0].Nested.ConnTimeout", EnvName: "DATABASES_0_nest_timeout", Value: "30s"},
+		{StructPath: "Databases[1].Host", EnvName: "databases_1_host", Value: "example.com"},
+	}, receiver.calls)
+}
+
+func TestEnv_UnmarshallWithCaseInsensitiveNamesOsEnv(t *testing.T) {
+	t.Setenv("goenvtest_host", "example.com")
+	t.Setenv("GOENVTEST_Port", "80")
+	actual := &struct {
+		Host string
+		Port int
+	}{}
+	err := New().WithPrefix("GOENVTEST").WithCaseInsensitiveNames().Unmarshall(actual)
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com", actual.Host)
+	assert.Equal(t, 80, actual.Port)
+}
+
+func TestEnv_UnmarshallWithCaseInsensitiveNamesConflict(t *testing.T) {
+	env := &envMock{
+		mock: map[string]string{
+			"Name": "a",
+			"NAME": "b",
+		},
+	}
+	err := NewWithEnvReader(env).WithCaseInsensitiveNames().Unmarshall(&appConfigMock{})
+	assert.Error(t, err)
+	if assert.IsType(t, &ParseError{}, err) {
+		assert.Equal(t, "Name", err.(*ParseError).Path.EnvPath)
+		assert.Contains(t, err.Error(), "differ only by case")
+	}
+}
+
+func TestEnv_UnmarshallTagOptions(t *testing.T) {
+	t.Run("defaults and secrets", func(t *testing.T) {
+		receiver := &setReceiverMock{}
+		env := &envMock{
+			mock: map[string]string{
+				"HOST":      "example.com",
+				"PASSWORD":  "s3cr3t",
+				"DB_0_Host": "db.example.com",
+			},
+		}
+		actual := &taggedConfigMock{}
+		err := NewWithParseRegistryEmitterEnvReader(defaultParseRegister, receiver, env).Unmarshall(actual)
+		assert.NoError(t, err)
+		assert.Equal(t, optional.IntFrom(8080), actual.Port)
+		assert.Equal(t, optional.StringFrom("s3cr3t"), actual.Password)
+		assert.ElementsMatch(t, []setReceiverMockCall{
+			{StructPath: "Host", EnvName: "HOST", Value: "example.com"},
+			{StructPath: "Password", EnvName: "PASSWORD", Value: RedactedValue},
+			{StructPath: "Databases[0].Host", EnvName: "DB_0_Host", Value: "db.example.com"},
+		}, receiver.calls)
+	})
+	t.Run("environment overrides default", func(t *testing.T) {
+		env := &envMock{mock: map[string]string{"PORT": "80", "HOST": "example.com", "DB_0_Host": "db"}}
+		actual := &taggedConfigMock{}
+		err := NewWithEnvReader(env).Unmarshall(actual)
+		assert.NoError(t, err)
+		assert.Equal(t, optional.IntFrom(80), actual.Port)
+	})
+	t.Run("required value", func(t *testing.T) {
+		env := &envMock{mock: map[string]string{"DB_0_Host": "db"}}
+		err := NewWithEnvReader(env).Unmarshall(&taggedConfigMock{})
+		assert.EqualError(t, err, "environment variable 'HOST' failed to parse because it is required, but was not set")
+		assert.True(t, errors.Is(err, ErrRequired))
+	})
+	t.Run("required slice", func(t *testing.T) {
+		env := &envMock{mock: map[string]string{"HOST": "example.com"}}
+		err := NewWithEnvReader(env).Unmarshall(&taggedConfigMock{})
+		assert.EqualError(t, err, "environment variable 'DB' failed to parse because it is required, but was not set")
+	})
+	t.Run("secret parse error", func(t *testing.T) {
+		type secretConfig struct {
+			Pin optional.Int `env:"PIN,secret"`
+		}
+		env := &envMock{mock: map[string]string{"PIN": "12x4"}}
+		err := NewWithEnvReader(env).Unmarshall(&secretConfig{})
+		assert.EqualError(t, err, "environment variable 'PIN' failed to parse because the secret value is not a valid optional.Int")
+	})
+	t.Run("invalid tag", func(t *testing.T) {
+		env := &envMock{mock: map[string]string{"Databases_0_HOST": "example.com"}}
+		err := NewWithEnvReader(env).Unmarshall(&invalidTagConfigMock{})
+		assert.EqualError(t, err, "env tag `HOST,bogus` on field 'Databases[].Host' is invalid because unknown option 'bogus'")
+		assert.IsType(t, &TagError{}, err)
+	})
+	t.Run("secret and default on structures", func(t *testing.T) {
+		cases := map[string]struct {
+			value    interface{}
+			expected string
+		}{
+			"secret structure": {
+				value: &struct {
+					DB dbConfigMock `env:",secret"`
+				}{},
+				expected: "env tag `,secret` on field 'DB' is invalid because option 'secret' can only be used on fields that hold values, not on structures",
+			},
+			"secret pointer to structure": {
+				value: &struct {
+					Redis *redisConfigMock `env:",secret"`
+				}{},
+				expected: "env tag `,secret` on field 'Redis' is invalid because option 'secret' can only be used on fields that hold values, not on structures",
+			},
+			"secret map of structures": {
+				value: &struct {
+					DBs map[string]dbConfigMock `env:",secret"`
+				}{},
+				expected: "env tag `,secret` on field 'DBs' is invalid because option 'secret' can only be used on fields that hold values, not on structures",
+			},
+			"default structure": {
+				value: &struct {
+					DB dbConfigMock `env:",default=x"`
+				}{},
+				expected: "env tag `,default=x` on field 'DB' is invalid because option 'default' can only be used on fields that hold values, not on structures",
+			},
+		}
+		for caseName, c := range cases {
+			t.Run(caseName, func(t *testing.T) {
+				err := NewWithEnvReader(&envMock{}).Unmarshall(c.value)
+				assert.EqualError(t, err, c.expected)
+				assert.IsType(t, &TagError{}, err)
+			})
+		}
+	})
+	t.Run("secret on lists and maps of values", func(t *testing.T) {
+		actual := &struct {
+			Tokens []string          `env:"TOKENS,secret"`
+			Keys   map[string]string `env:"KEYS,secret"`
+			Pin    *int              `env:"PIN,secret"`
+		}{}
+		env := &envMock{mock: map[string]string{"TOKENS": "a,b", "KEYS": "x=1", "PIN": "1"}}
+		err := NewWithEnvReader(env).Unmarshall(actual)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, actual.Tokens)
+	})
+}
+
+func TestEnv_UnmarshallAliases(t *testing.T) {
+	cases := map[string]struct {
+		env                map[string]string
+		expectedHost       optional.String
+		expectedReplicas   int
+		expectedDeprecated []setReceiverMockCall
+	}{
+		"preferred name": {
+			env:          map[string]string{"DATABASE_HOST": "a", "DB_SERVER": "b", "DB_HOST": "c"},
+			expectedHost: optional.StringFrom("a"),
+		},
+		"alias before deprecated": {
+			env:          map[string]string{"DB_SERVER": "b", "DB_HOST": "c"},
+			expectedHost: optional.StringFrom("b"),
+		},
+		"deprecated in order": {
+			env:          map[string]string{"HOST": "d", "DB_HOST": "c"},
+			expectedHost: optional.StringFrom("c"),
+			expectedDeprecated: []setReceiverMockCall{
+				{StructPath: "Host", EnvName: "DB_HOST", Value: "DATABASE_HOST"},
+			},
+		},
+		"deprecated slice name": {
+			env:              map[string]string{"REPLICA_0_PORT": "1", "SLAVE_1_PORT": "2", "REPLICA_2_DB_PORT": "3"},
+			expectedReplicas: 3,
+			expectedDeprecated: []setReceiverMockCall{
+				{StructPath: "Replicas[1].Port", EnvName: "SLAVE_1_PORT", Value: "REPLICA_1_PORT"},
+				{StructPath: "Replicas[2].Port", EnvName: "REPLICA_2_DB_PORT", Value: "REPLICA_2_PORT"},
+			},
+		},
+	}
+
+	for caseName, c := range cases {
+		t.Run(caseName, func(t *testing.T) {
+			receiver := &deprecationReceiverMock{}
+			actual := &aliasedConfigMock{}
+			err := NewWithParseRegistryEmitterEnvReader(defaultParseRegister, receiver, &envMock{mock: c.env}).Unmarshall(actual)
+			assert.NoError(t, err)
+			assert.Equal(t, c.expectedHost, actual.Host)
+			assert.Len(t, actual.Replicas, c.expectedReplicas)
+			assert.Equal(t, c.expectedDeprecated, receiver.deprecated)
+		})
+	}
+}
+
+func TestEnv_UnmarshallAliasCollision(t *testing.T) {
+	type config struct {
+		Host optional.String `env:"HOST,deprecated=SERVER"`
+		Name optional.String `env:"SERVER"`
+	}
+	err := NewWithEnvReader(&envMock{}).Unmarshall(&config{})
+	assert.EqualError(t, err, "fields 'Host' and 'Name' both read environment variable 'SERVER'")
+}
+
+func TestEnv_Check(t *testing.T) {
+	type taggedIndex struct {
+		Databases []dbConfigMock
+		Primary   optional.String `env:"Databases_0_Host"`
+	}
+	type taggedSliceLength struct {
+		Hosts []optional.String
+		Count optional.Int `env:"Hosts_5"`
+	}
+	type taggedFile struct {
+		Password     optional.String
+		PasswordFile optional.String `env:"Password_FILE"`
+	}
+	type taggedCase struct {
+		Host optional.String `env:"host"`
+		Name optional.String `env:"HOST"`
+	}
+	cases := map[string]struct {
+		t        reflect.Type
+		env      *Env
+		expected []NameCollision
+	}{
+		"no collisions": {
+			t:   reflect.TypeOf(appConfigMock{}),
+			env: New(),
+		},
+		"pointer to structure": {
+			t:   reflect.TypeOf(&appConfigMock{}),
+			env: New(),
+		},
+		"name looks like an element": {
+			t:   reflect.TypeOf(taggedIndex{}),
+			env: New(),
+			expected: []NameCollision{
+				{StructPaths: [2]string{"Databases", "Primary"}, EnvPath: "Databases_0_Host"},
+				{StructPaths: [2]string{"Databases[].Host", "Primary"}, EnvPath: "Databases_0_Host"},
+			},
+		},
+		"name counted as an element": {
+			t:   reflect.TypeOf(taggedSliceLength{}),
+			env: New(),
+			expected: []NameCollision{
+				{StructPaths: [2]string{"Hosts", "Count"}, EnvPath: "Hosts_5"},
+			},
+		},
+		"file suffix": {
+			t:   reflect.TypeOf(taggedFile{}),
+			env: New().WithFileSuffix(DefaultFileSuffix),
+			expected: []NameCollision{
+				{StructPaths: [2]string{"Password", "PasswordFile"}, EnvPath: "Password_FILE"},
+			},
+		},
+		"file suffix disabled": {
+			t:   reflect.TypeOf(taggedFile{}),
+			env: New(),
+		},
+		"case insensitive": {
+			t:   reflect.TypeOf(taggedCase{}),
+			env: New().WithCaseInsensitiveNames(),
+			expected: []NameCollision{
+				{StructPaths: [2]string{"Host", "Name"}, EnvPath: "host"},
+			},
+		},
+	}
+
+	for caseName, c := range cases {
+		t.Run(caseName, func(t *testing.T) {
+			err := c.env.Check(c.t)
+			if c.expected == nil {
+				assert.NoError(t, err)
+			} else if assert.IsType(t, &NameCollisionError{}, err) {
+				assert.Equal(t, c.expected, err.(*NameCollisionError).Collisions)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	assert.NoError(t, Check(reflect.TypeOf(appConfigMock{})))
+	assert.EqualError(t, Check(reflect.TypeOf(0)), "only structure types can be checked, not int")
+	assert.EqualError(t, Check(reflect.TypeOf(&invalidTagConfigMock{})), "env tag `HOST,bogus` on field 'Databases[].Host' is invalid because unknown option 'bogus'")
+}
+
+func TestEnv_UnmarshallStructMap(t *testing.T) {
+	cases := map[string]struct {
+		env      map[string]string
+		expected map[string]dbConfigMock
+	}{
+		"not set": {},
+		"keys": {
+			env: map[string]string{
+				"DB_primary_Host":         "a",
+				"DB_replica_Host":         "b",
+				"DB_replica_NEST_TIMEOUT": "30s",
+			},
+			expected: map[string]dbConfigMock{
+				"primary": {Host: optional.StringFrom("a")},
+				"replica": {Host: optional.StringFrom("b"), Nested: nestedDbConfigMock{ConnTimeout: optional.DurationFrom(30 * time.Second)}},
+			},
+		},
+		"alias": {
+			env: map[string]string{
+				"DB_primary_Host":       "a",
+				"DATABASE_primary_Host": "ignored",
+				"DATABASE_primary_User": "admin",
+				"DATABASE_replica_Host": "b",
+			},
+			expected: map[string]dbConfigMock{
+				"primary": {Host: optional.StringFrom("a"), User: optional.StringFrom("admin")},
+				"replica": {Host: optional.StringFrom("b")},
+			},
+		},
+		"keys cannot contain separators": {
+			env: map[string]string{
+				"DB_us_east_Host": "a",
+			},
+		},
+		"no field after the key": {
+			env: map[string]string{
+				"DB_primary": "a",
+				"DB_":        "b",
+				"DB__Host":   "c",
+			},
+		},
+	}
+
+	for caseName, c := range cases {
+		t.Run(caseName, func(t *testing.T) {
+			actual := &namedDatabasesMock{}
+			err := NewWithEnvReader(&envMock{mock: c.env}).Unmarshall(actual)
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, actual.Databases)
+		})
+	}
+}
+
+func TestEnv_UnmarshallStructMapDetails(t *testing.T) {
+	t.Run("receiver", func(t *testing.T) {
+		receiver := &setReceiverMock{}
+		env := &envMock{mock: map[string]string{"DB_primary_Host": "a"}}
+		err := NewWithParseRegistryEmitterEnvReader(defaultParseRegister, receiver, env).Unmarshall(&namedDatabasesMock{})
+		assert.NoError(t, err)
+		assert.Equal(t, []setReceiverMockCall{
+			{StructPath: "Databases", EnvName: "DB_primary_Host", Value: "a"},
+		}, receiver.calls)
+	})
+	t.Run("existing elements are kept", func(t *testing.T) {
+		env := &envMock{mock: map[string]string{"DB_primary_User": "admin"}}
+		actual := &namedDatabasesMock{
+			Databases: map[string]dbConfigMock{
+				"primary": {Host: optional.StringFrom("a")},
+				"other":   {Host: optional.StringFrom("b")},
+			},
+		}
+		err := NewWithEnvReader(env).Unmarshall(actual)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]dbConfigMock{
+			"primary": {Host: optional.StringFrom("a"), User: optional.StringFrom("admin")},
+			"other":   {Host: optional.StringFrom("b")},
+		}, actual.Databases)
+	})
+	t.Run("parse error", func(t *testing.T) {
+		env := &envMock{mock: map[string]string{"DB_primary_NEST_TIMEOUT": "P30s"}}
+		err := NewWithEnvReader(env).Unmarshall(&namedDatabasesMock{})
+		if assert.IsType(t, &ParseError{}, err) {
+			assert.Equal(t, StructEnvPath{
+				StructPath: "Databases[primary].Nested.ConnTimeout",
+				EnvPath:    "DB_primary_NEST_TIMEOUT",
+			}, err.(*ParseError).Path)
+		}
+	})
+	t.Run("required", func(t *testing.T) {
+		actual := &struct {
+			Databases map[string]dbConfigMock `env:"DB,required"`
+		}{}
+		err := NewWithEnvReader(&envMock{}).Unmarshall(actual)
+		assert.EqualError(t, err, "environment variable 'DB' failed to parse because it is required, but was not set")
+	})
+	t.Run("collision with a key", func(t *testing.T) {
+		type config struct {
+			Databases map[string]dbConfigMock
+			Primary   optional.String `env:"Databases_primary_Host"`
+		}
+		err := Check(reflect.TypeOf(config{}))
+		if assert.IsType(t, &NameCollisionError{}, err) {
+			assert.Equal(t, []NameCollision{
+				{StructPaths: [2]string{"Databases", "Primary"}, EnvPath: "Databases_primary_Host"},
+				{StructPaths: [2]string{"Databases[].Host", "Primary"}, EnvPath: "Databases_primary_Host"},
+			}, err.(*NameCollisionError).Collisions)
+		}
+	})
+}
+
+func TestEnv_UnmarshallAbsolute(t *testing.T) {
+	type serverConfig struct {
+		Host  optional.String
+		Port  optional.Int    `env:"PORT,absolute"`
+		Proxy optional.String `env:"HTTP_PROXY,absolute,alias=http_proxy" envPrefix:"IGNORED"`
+	}
+	type config struct {
+		Server serverConfig `envPrefix:"PUBLIC"`
+	}
+	t.Run("ignores parents and prefix", func(t *testing.T) {
+		env := &envMock{
+			mock: map[string]string{
+				"APP_PUBLIC_Server_Host": "example.com",
+				"PORT":                   "8080",
+				"http_proxy":             "proxy.example.com",
+				"APP_PUBLIC_Server_PORT": "ignored",
+			},
+		}
+		actual := &config{}
+		err := NewWithEnvReader(env).WithPrefix("APP").Unmarshall(actual)
+		assert.NoError(t, err)
+		assert.Equal(t, serverConfig{
+			Host:  optional.StringFrom("example.com"),
+			Port:  optional.IntFrom(8080),
+			Proxy: optional.StringFrom("proxy.example.com"),
+		}, actual.Server)
+	})
+	t.Run("inside a slice", func(t *testing.T) {
+		actual := &struct {
+			Servers []serverConfig
+		}{}
+		err := NewWithEnvReader(&envMock{}).Unmarshall(actual)
+		assert.EqualError(t, err, "env tag `PORT,absolute` on field 'Servers[].Port' is invalid because option 'absolute' cannot be used inside slices or maps, as every element would read the same variable")
+	})
+	t.Run("inside a map", func(t *testing.T) {
+		actual := &struct {
+			Servers map[string]serverConfig
+		}{}
+		err := NewWithEnvReader(&envMock{}).Unmarshall(actual)
+		assert.IsType(t, &TagError{}, err)
+	})
+}
+
+func TestEnv_UnmarshallPointers(t *testing.T) {
+	name := "SuperServer"
+	threads := 4
+	cases := map[string]struct {
+		env      map[string]string
+		expected pointerConfigMock
+	}{
+		"not configured": {
+			expected: pointerConfigMock{Threads: &threads},
+		},
+		"value": {
+			env:      map[string]string{"Name": "SuperServer"},
+			expected: pointerConfigMock{Name: &name, Threads: &threads},
+		},
+		"structure": {
+			env: map[string]string{"Redis_Host": "cache.example.com"},
+			expected: pointerConfigMock{
+				Threads: &threads,
+				Redis:   &redisConfigMock{Host: optional.StringFrom("cache.example.com"), Port: optional.IntFrom(6379)},
+			},
+		},
+		"slice elements": {
+			env: map[string]string{"Replicas_1_Host": "db.example.com"},
+			expected: pointerConfigMock{
+				Threads:  &threads,
+				Replicas: []*dbConfigMock{nil, {Host: optional.StringFrom("db.example.com")}},
+			},
+		},
+	}
+
+	for caseName, c := range cases {
+		t.Run(caseName, func(t *testing.T) {
+			actual := &pointerConfigMock{}
+			err := NewWithEnvReader(&envMock{mock: c.env}).Unmarshall(actual)
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, *actual)
+		})
+	}
+}
+
+func TestEnv_UnmarshallPointerDetails(t *testing.T) {
+	t.Run("allocated pointers are filled in place", func(t *testing.T) {
+		redis := &redisConfigMock{Host: optional.StringFrom("cache.example.com")}
+		actual := &pointerConfigMock{Redis: redis}
+		err := NewWithEnvReader(&envMock{mock: map[string]string{"Redis_PORT": "7000"}}).Unmarshall(actual)
+		assert.NoError(t, err)
+		assert.Same(t, redis, actual.Redis)
+		assert.Equal(t, redisConfigMock{Host: optional.StringFrom("cache.example.com"), Port: optional.IntFrom(7000)}, *redis)
+	})
+	t.Run("receiver is given the path to the pointer", func(t *testing.T) {
+		receiver := &setReceiverMock{}
+		env := &envMock{mock: map[string]string{"Name": "SuperServer", "Redis_Host": "cache.example.com"}}
+		err := NewWithParseRegistryEmitterEnvReader(defaultParseRegister, receiver, env).Unmarshall(&pointerConfigMock{})
+		assert.NoError(t, err)
+		assert.Equal(t, []setReceiverMockCall{
+			{StructPath: "Name", EnvName: "Name", Value: "SuperServer"},
+			{StructPath: "Redis", EnvName: "Redis_Host", Value: "cache.example.com"},
+		}, receiver.calls)
+	})
+	t.Run("parse error", func(t *testing.T) {
+		err := NewWithEnvReader(&envMock{mock: map[string]string{"Redis_PORT": "x"}}).Unmarshall(&pointerConfigMock{})
+		if assert.IsType(t, &ParseError{}, err) {
+			assert.Equal(t, StructEnvPath{StructPath: "Redis.Port", EnvPath: "Redis_PORT"}, err.(*ParseError).Path)
+		}
+	})
+	t.Run("absolute fields allocate the pointer", func(t *testing.T) {
+		type serverConfig struct {
+			Host string
+			Port int `env:"PORT,absolute"`
+		}
+		actual := &struct {
+			Server *serverConfig
+			Other  *serverConfig `env:"-"`
+		}{}
+		err := NewWithEnvReader(&envMock{mock: map[string]string{"PORT": "8080"}}).WithPrefix("APP").Unmarshall(actual)
+		assert.NoError(t, err)
+		if assert.NotNil(t, actual.Server) {
+			assert.Equal(t, serverConfig{Port: 8080}, *actual.Server)
+		}
+	})
+	t.Run("nested absolute fields allocate the pointer", func(t *testing.T) {
+		type proxyConfig struct {
+			URL string `env:"HTTP_PROXY,absolute"`
+		}
+		actual := &struct {
+			Client *struct {
+				Proxy proxyConfig
+			}
+		}{}
+		err := NewWithEnvReader(&envMock{mock: map[string]string{"HTTP_PROXY": "http://proxy"}}).Unmarshall(actual)
+		assert.NoError(t, err)
+		if assert.NotNil(t, actual.Client) {
+			assert.Equal(t, "http://proxy", actual.Client.Proxy.URL)
+		}
+	})
+	t.Run("required", func(t *testing.T) {
+		actual := &struct {
+			Redis *redisConfigMock `env:",required"`
+		}{}
+		err := NewWithEnvReader(&envMock{}).Unmarshall(actual)
+		assert.EqualError(t, err, "environment variable 'Redis' failed to parse because it is required, but was not set")
+	})
+}
+
+func TestEnv_UnmarshallTextUnmarshaler(t *testing.T) {
+	t.Run("values", func(t *testing.T) {
+		env := &envMock{
+			mock: map[string]string{
+				"Level":      "debug",
+				"ID":         "ABC",
+				"Levels_0_":  "info",
+				"Levels_1_":  "debug",
+				"Optional":   "info",
+				"List":       "a,b",
+				"List_0_":    "ignored",
+				"Unrelated":  "x",
+				"ID_Ignored": "x",
+			},
+		}
+		actual := &textConfigMock{}
+		err := NewWithEnvReader(env).Unmarshall(actual)
+		assert.NoError(t, err)
+		info := levelMock(2)
+		assert.Equal(t, textConfigMock{
+			Level:    1,
+			ID:       idMock{value: "abc"},
+			Levels:   []levelMock{2, 1},
+			Optional: &info,
+			List:     listMock{"a", "b"},
+		}, *actual)
+	})
+	t.Run("parse error", func(t *testing.T) {
+		env := &envMock{mock: map[string]string{"Levels_0_": "loud"}}
+		err := NewWithEnvReader(env).Unmarshall(&textConfigMock{})
+		assert.EqualError(t, err, "environment variable 'Levels_0_' failed to parse because unknown level 'loud'")
+		assert.IsType(t, &ParseError{}, err)
+	})
+	t.Run("secret parse error", func(t *testing.T) {
+		actual := &struct {
+			Level levelMock `env:",secret"`
+		}{}
+		err := NewWithEnvReader(&envMock{mock: map[string]string{"Level": "loud"}}).Unmarshall(actual)
+		assert.EqualError(t, err, "environment variable 'Level' failed to parse because the secret value is not a valid v2.levelMock")
+	})
+	t.Run("registry first", func(t *testing.T) {
+		actual := &struct {
+			Timeout optional.Duration
+		}{}
+		err := NewWithEnvReader(&envMock{mock: map[string]string{"Timeout": "5s"}}).Unmarshall(actual)
+		assert.NoError(t, err)
+		assert.Equal(t, optional.DurationFrom(5*time.Second), actual.Timeout)
+	})
+}
+
+func TestEnv_UnmarshallUnsetPrimitives(t *testing.T) {
+	actual := &struct {
+		Port int
+		Name string
+	}{Port: 80}
+	err := NewWithEnvReader(&envMock{}).Unmarshall(actual)
+	assert.NoError(t, err)
+	assert.Equal(t, 80, actual.Port)
+}
+
+func TestEnv_UnmarshallList(t *testing.T) {
+	cases := map[string]struct {
+		env      map[string]string
+		expected listConfigMock
+	}{
+		"lists": {
+			env: map[string]string{
+				"ORIGINS": "a.com, b.com",
+				"PORTS":   "80;443",
+				"HOSTS":   `x|"y|z"`,
+			},
+			expected: listConfigMock{
+				Origins: []string{"a.com", "b.com"},
+				Ports:   []int{80, 443},
+				Hosts:   []string{"x", "y|z"},
+			},
+		},
+		"indexed": {
+			env: map[string]string{
+				"ORIGINS_1_": "b.com",
+				"HOSTS_0_":   "x",
+			},
+			expected: listConfigMock{
+				Origins: []string{"", "b.com"},
+				Hosts:   []string{"x"},
+			},
+		},
+		"default": {
+			expected: listConfigMock{
+				Hosts: []string{"a", "b"},
+			},
+		},
+	}
+
+	for caseName, c := range cases {
+		t.Run(caseName, func(t *testing.T) {
+			actual := &listConfigMock{}
+			err := NewWithEnvReader(&envMock{mock: c.env}).Unmarshall(actual)
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, *actual)
+		})
+	}
+}
+
+func TestEnv_UnmarshallListDetails(t *testing.T) {
+	t.Run("list and indexed", func(t *testing.T) {
+		env := &envMock{mock: map[string]string{"ORIGINS": "a.com", "ORIGINS_0_": "b.com"}}
+		err := NewWithEnvReader(env).Unmarshall(&listConfigMock{})
+		assert.EqualError(t, err, "environment variable 'ORIGINS' failed to parse because it is set as a list, but variables with indexes are also set, set only one of them")
+		assert.True(t, errors.Is(err, ErrListAndIndexed))
+	})
+	t.Run("element parse error", func(t *testing.T) {
+		env := &envMock{mock: map[string]string{"PORTS": "80;x"}}
+		err := NewWithEnvReader(env).Unmarshall(&listConfigMock{})
+		if assert.IsType(t, &ParseError{}, err) {
+			assert.Equal(t, StructEnvPath{StructPath: "Ports[1]", EnvPath: "PORTS"}, err.(*ParseError).Path)
+		}
+	})
+	t.Run("unterminated quote", func(t *testing.T) {
+		env := &envMock{mock: map[string]string{"ORIGINS": `"a.com`}}
+		err := NewWithEnvReader(env).Unmarshall(&listConfigMock{})
+		assert.EqualError(t, err, "environment variable 'ORIGINS' failed to parse because a quote is not closed")
+	})
+	t.Run("receiver", func(t *testing.T) {
+		receiver := &setReceiverMock{}
+		env := &envMock{mock: map[string]string{"ORIGINS": "a.com,b.com"}}
+		err := NewWithParseRegistryEmitterEnvReader(defaultParseRegister, receiver, env).Unmarshall(&listConfigMock{})
+		assert.NoError(t, err)
+		assert.Equal(t, []setReceiverMockCall{
+			{StructPath: "Origins[0]", EnvName: "ORIGINS", Value: "a.com"},
+			{StructPath: "Origins[1]", EnvName: "ORIGINS", Value: "b.com"},
+		}, receiver.calls)
+	})
+	t.Run("list separator", func(t *testing.T) {
+		env := &envMock{mock: map[string]string{"ORIGINS": "a.com b.com", "PORTS": "80;443"}}
+		actual := &listConfigMock{}
+		err := NewWithEnvReader(env).WithListSeparator(" ").Unmarshall(actual)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a.com", "b.com"}, actual.Origins)
+		assert.Equal(t, []int{80, 443}, actual.Ports)
+	})
+	t.Run("lists disabled", func(t *testing.T) {
+		env := &envMock{mock: map[string]string{"ORIGINS": "a.com,b.com"}}
+		actual := &listConfigMock{}
+		err := NewWithEnvReader(env).WithListSeparator("").Unmarshall(actual)
+		assert.NoError(t, err)
+		assert.Nil(t, actual.Origins)
+	})
+	t.Run("separator on a value", func(t *testing.T) {
+		actual := &struct {
+			Server struct {
+				Host string `env:"HOST,sep=;"`
+			}
+		}{}
+		err := NewWithEnvReader(&envMock{}).Unmarshall(actual)
+		assert.EqualError(t, err, "env tag `HOST,sep=;` on field 'Server.Host' is invalid because option 'sep' can only be used on slice and map fields")
+		assert.IsType(t, &TagError{}, err)
+	})
+	t.Run("structures are not lists", func(t *testing.T) {
+		env := &envMock{mock: map[string]string{"Databases": "a,b"}}
+		actual := &appConfigMock{}
+		err := NewWithEnvReader(env).Unmarshall(actual)
+		assert.NoError(t, err)
+		assert.Nil(t, actual.Databases)
+	})
+}
+
+func TestEnv_UnmarshallNestedSlices(t *testing.T) {
+	cases := map[string]struct {
+		env      map[string]string
+		expected matrixConfigMock
+	}{
+		"not set": {},
+		"indexes": {
+			env: map[string]string{"Matrix_0_1_": "5", "Matrix_2_0_": "7"},
+			expected: matrixConfigMock{
+				Matrix: [][]int{{0, 5}, nil, {7}},
+			},
+		},
+		"inner lists": {
+			env: map[string]string{"Matrix_0_": "1,2", "Matrix_1_0_": "3"},
+			expected: matrixConfigMock{
+				Matrix: [][]int{{1, 2}, {3}},
+			},
+		},
+		"three levels": {
+			env: map[string]string{"CUBE_0_1_0_": "x", "CUBE_1_0_": "a,b"},
+			expected: matrixConfigMock{
+				Cube: [][][]string{{nil, {"x"}}, {{"a", "b"}}},
+			},
+		},
+		"structures": {
+			env: map[string]string{"Groups_1_0_Host": "cache.example.com", "Groups_1_1_PORT": "7000"},
+			expected: matrixConfigMock{
+				Groups: [][]redisConfigMock{nil, {{Host: optional.StringFrom("cache.example.com"), Port: optional.IntFrom(6379)}, {Port: optional.IntFrom(7000)}}},
+			},
+		},
+	}
+
+	for caseName, c := range cases {
+		t.Run(caseName, func(t *testing.T) {
+			actual := &matrixConfigMock{}
+			err := NewWithEnvReader(&envMock{mock: c.env}).Unmarshall(actual)
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, *actual)
+		})
+	}
+}
+
+func TestEnv_UnmarshallNestedSliceDetails(t *testing.T) {
+	t.Run("parse error", func(t *testing.T) {
+		err := NewWithEnvReader(&envMock{mock: map[string]string{"Groups_1_0_PORT": "x"}}).Unmarshall(&matrixConfigMock{})
+		if assert.IsType(t, &ParseError{}, err) {
+			assert.Equal(t, StructEnvPath{StructPath: "Groups[1][0].Port", EnvPath: "Groups_1_0_PORT"}, err.(*ParseError).Path)
+		}
+	})
+	t.Run("receiver is given the path to the outer element", func(t *testing.T) {
+		receiver := &setReceiverMock{}
+		env := &envMock{mock: map[string]string{"Matrix_0_1_": "5"}}
+		err := NewWithParseRegistryEmitterEnvReader(defaultParseRegister, receiver, env).Unmarshall(&matrixConfigMock{})
+		assert.NoError(t, err)
+		assert.Equal(t, []setReceiverMockCall{
+			{StructPath: "Matrix[0]", EnvName: "Matrix_0_1_", Value: "5"},
+		}, receiver.calls)
+	})
+}
+
+func TestEnv_UnmarshallScalarMap(t *testing.T) {
+	cases := map[string]struct {
+		env      map[string]string
+		expected labelsConfigMock
+	}{
+		"not set": {},
+		"list": {
+			env: map[string]string{
+				"LABELS": "team=core, tier=1,empty=,eq=a=b",
+				"LIMITS": "acme=10;globex=20",
+			},
+			expected: labelsConfigMock{
+				Labels: map[string]string{"team": "core", "tier": "1", "empty": "", "eq": "a=b"},
+				Limits: map[string]int{"acme": 10, "globex": 20},
+			},
+		},
+		"suffixes": {
+			env: map[string]string{
+				"LABELS_team":      "core",
+				"LABELS_cost_code": "42",
+				"LIMITS_acme":      "10",
+			},
+			expected: labelsConfigMock{
+				Labels: map[string]string{"team": "core", "cost_code": "42"},
+				Limits: map[string]int{"acme": 10},
+			},
+		},
+		"suffix overrides list": {
+			env: map[string]string{
+				"LABELS":      "team=core,tier=1",
+				"LABELS_team": "edge",
+			},
+			expected: labelsConfigMock{
+				Labels: map[string]string{"team": "edge", "tier": "1"},
+			},
+		},
+	}
+
+	for caseName, c := range cases {
+		t.Run(caseName, func(t *testing.T) {
+			actual := &labelsConfigMock{}
+			err := NewWithEnvReader(&envMock{mock: c.env}).Unmarshall(actual)
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, *actual)
+		})
+	}
+}
+
+func TestEnv_UnmarshallScalarMapOsEnv(t *testing.T) {
+	t.Setenv("GOENVTEST_LABELS_team", "core")
+	t.Setenv("GOENVTEST_LIMITS_acme", "3")
+	actual := &labelsConfigMock{}
+	err := New().WithPrefix("GOENVTEST").Unmarshall(actual)
+	assert.NoError(t, err)
+	assert.Equal(t, labelsConfigMock{
+		Labels: map[string]string{"team": "core"},
+		Limits: map[string]int{"acme": 3},
+	}, *actual)
+}
+
+func TestEnv_UnmarshallScalarMapDetails(t *testing.T) {
+	t.Run("receiver", func(t *testing.T) {
+		receiver := &setReceiverMock{}
+		env := &envMock{mock: map[string]string{"LABELS": "team=core", "LABELS_tier": "1"}}
+		err := NewWithParseRegistryEmitterEnvReader(defaultParseRegister, receiver, env).Unmarshall(&labelsConfigMock{})
+		assert.NoError(t, err)
+		assert.Equal(t, []setReceiverMockCall{
+			{StructPath: "Labels", EnvName: "LABELS", Value: "team=core"},
+			{StructPath: "Labels", EnvName: "LABELS_tier", Value: "1"},
+		}, receiver.calls)
+	})
+	t.Run("existing entries are kept", func(t *testing.T) {
+		actual := &labelsConfigMock{Labels: map[string]string{"team": "core", "tier": "1"}}
+		err := NewWithEnvReader(&envMock{mock: map[string]string{"LABELS_tier": "2"}}).Unmarshall(actual)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"team": "core", "tier": "2"}, actual.Labels)
+	})
+	t.Run("value parse error", func(t *testing.T) {
+		err := NewWithEnvReader(&envMock{mock: map[string]string{"LIMITS": "acme=x"}}).Unmarshall(&labelsConfigMock{})
+		if assert.IsType(t, &ParseError{}, err) {
+			assert.Equal(t, StructEnvPath{StructPath: "Limits[acme]", EnvPath: "LIMITS"}, err.(*ParseError).Path)
+		}
+	})
+	t.Run("not a key=value pair", func(t *testing.T) {
+		err := NewWithEnvReader(&envMock{mock: map[string]string{"LABELS": "team=core,edge"}}).Unmarshall(&labelsConfigMock{})
+		assert.EqualError(t, err, "environment variable 'LABELS' failed to parse because every item must be a key=value pair")
+	})
+	t.Run("file suffix", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "go-env-labels")
+		assert.NoError(t, err)
+		defer func() {
+			_ = os.RemoveAll(dir)
+		}()
+		file := filepath.Join(dir, "labels")
+		assert.NoError(t, ioutil.WriteFile(file, []byte("team=core\n"), 0600))
+		actual := &labelsConfigMock{}
+		err = NewWithEnvReader(&envMock{mock: map[string]string{"LABELS_FILE": file}}).WithFileSuffix(DefaultFileSuffix).Unmarshall(actual)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"team": "core"}, actual.Labels)
+	})
+	t.Run("collision with an entry", func(t *testing.T) {
+		type config struct {
+			Labels map[string]string
+			Team   optional.String `env:"Labels_team"`
+		}
+		err := Check(reflect.TypeOf(config{}))
+		assert.EqualError(t, err, "fields 'Labels' and 'Team' both read environment variable 'Labels_team'")
+	})
+}
+
+func TestEnv_UnmarshallInline(t *testing.T) {
+	t.Run("tagged", func(t *testing.T) {
+		env := &envMock{
+			mock: map[string]string{
+				"CertFile":     "shared.pem",
+				"TLS_CertFile": "tls.pem",
+				"Port":         "443",
+			},
+		}
+		actual := &struct {
+			TLSConfig TLSConfigMock `env:"TLS"`
+			Shared    TLSConfigMock `env:",inline"`
+			Port      optional.Int
+		}{}
+		err := NewWithEnvReader(env).Unmarshall(actual)
+		assert.NoError(t, err)
+		assert.Equal(t, optional.StringFrom("shared.pem"), actual.Shared.CertFile)
+		assert.Equal(t, optional.StringFrom("tls.pem"), actual.TLSConfig.CertFile)
+		assert.Equal(t, optional.IntFrom(443), actual.Port)
+	})
+	t.Run("embedded", func(t *testing.T) {
+		env := &envMock{
+			mock: map[string]string{
+				"HTTP_CertFile": "http.pem",
+				"GRPC_CertFile": "grpc.pem",
+				"GRPC_Name":     "grpc",
+			},
+		}
+		actual := &inlineServersMock{}
+		err := NewWithEnvReader(env).WithInlineEmbedded().Unmarshall(actual)
+		assert.NoError(t, err)
+		assert.Equal(t, optional.StringFrom("http.pem"), actual.HTTP.CertFile)
+		assert.Equal(t, optional.StringFrom("grpc.pem"), actual.GRPC.CertFile)
+		assert.Equal(t, optional.StringFrom("grpc"), actual.GRPC.Name)
+	})
+	t.Run("embedded not inline by default", func(t *testing.T) {
+		env := &envMock{
+			mock: map[string]string{
+				"HTTP_CertFile": "ignored.pem",
+			},
+		}
+		actual := &inlineServersMock{}
+		err := NewWithEnvReader(env).Unmarshall(actual)
+		assert.NoError(t, err)
+		assert.False(t, actual.HTTP.CertFile.IsSet())
+	})
+	t.Run("collision with promoted field", func(t *testing.T) {
+		err := NewWithEnvReader(&envMock{}).Unmarshall(&inlineCollisionMock{})
+		assert.EqualError(t, err, "fields 'CertFile' and 'TLS.CertFile' both read environment variable 'CertFile'")
+		assert.IsType(t, &NameCollisionError{}, err)
+	})
+	t.Run("collision between embedded and inline", func(t *testing.T) {
+		err := NewWithEnvReader(&envMock{}).WithInlineEmbedded().Unmarshall(&httpServerMock{})
+		if assert.IsType(t, &NameCollisionError{}, err) {
+			assert.Equal(t, []NameCollision{
+				{StructPaths: [2]string{"TLSConfigMock.CertFile", "Shared.CertFile"}, EnvPath: "CertFile"},
+				{StructPaths: [2]string{"TLSConfigMock.KeyFile", "Shared.KeyFile"}, EnvPath: "KeyFile"},
+			}, err.(*NameCollisionError).Collisions)
+		}
+	})
+	t.Run("embedded unexported types are skipped", func(t *testing.T) {
+		actual := &unexportedEmbeddedMock{}
+		env := &envMock{mock: map[string]string{"CertFile": "c", "Port": "80"}}
+		err := NewWithEnvReader(env).WithInlineEmbedded().Unmarshall(actual)
+		assert.NoError(t, err)
+		assert.Equal(t, unexportedEmbeddedMock{Port: optional.IntFrom(80)}, *actual)
+	})
+	t.Run("inline on a value", func(t *testing.T) {
+		actual := &struct {
+			Port int `env:",inline"`
+		}{}
+		err := NewWithEnvReader(&envMock{}).Unmarshall(actual)
+		assert.EqualError(t, err, "env tag `,inline` on field 'Port' is invalid because option 'inline' can only be used on struct fields")
+	})
+}
+
+func TestEnv_UnmarshallSkipsFields(t *testing.T) {
+	env := &envMock{
+		mock: map[string]string{
+			"Name":           "SuperServer",
+			"Cache":          "ignored",
+			"Computed_Host":  "ignored",
+			"Skipped_0_Host": "ignored",
+			"Callback":       "ignored",
+			"-":              "dash",
+			"mutex":          "ignored",
+			"unexported":     "ignored",
+		},
+	}
+	receiver := &setReceiverMock{}
+	actual := &runtimeStateMock{}
+	err := NewWithParseRegistryEmitterEnvReader(defaultParseRegister, receiver, env).Unmarshall(actual)
+	assert.NoError(t, err)
+	assert.Equal(t, optional.StringFrom("SuperServer"), actual.Name)
+	assert.Equal(t, optional.StringFrom("dash"), actual.Dash)
+	assert.Nil(t, actual.Cache)
+	assert.False(t, actual.Computed.Host.IsSet())
+	assert.Nil(t, actual.Skipped)
+	assert.False(t, actual.unexported.IsSet())
+	assert.ElementsMatch(t, []setReceiverMockCall{
+		{StructPath: "Name", EnvName: "Name", Value: "SuperServer"},
+		{StructPath: "Dash", EnvName: "-", Value: "dash"},
+	}, receiver.calls)
+
+	fields, err := NewWithEnvReader(env).config.analyzeType(reflect.TypeOf(runtimeStateMock{}))
+	assert.NoError(t, err)
+	assert.Len(t, fields, 2)
+}
+
+func TestEnv_UnmarshallNetwork(t *testing.T) {
+	t.Run("set", func(t *testing.T) {
+		env := &envMock{mock: map[string]string{
+			"IP":       "10.0.0.1",
+			"Addr":     "::1",
+			"Subnet":   "10.1.2.3/16",
+			"Prefix":   "192.168.0.0/24",
+			"Listen":   "[::1]:8080",
+			"REDIS":    "cache.internal",
+			"PEERS":    "a.internal,b.internal:7001",
+			"ENDPOINT": "HTTPS://api.example.com/v1",
+		}}
+		actual := &networkConfigMock{}
+		err := NewWithEnvReader(env).Unmarshall(actual)
+		assert.NoError(t, err)
+		assert.True(t, net.ParseIP("10.0.0.1").Equal(actual.IP))
+		assert.Equal(t, netip.MustParseAddr("::1"), actual.Addr)
+		if assert.NotNil(t, actual.Subnet) {
+			assert.Equal(t, "10.1.0.0/16", actual.Subnet.String())
+		}
+		assert.Equal(t, netip.MustParsePrefix("192.168.0.0/24"), actual.Prefix)
+		assert.Equal(t, netip.MustParseAddrPort("[::1]:8080"), actual.Listen)
+		assert.Equal(t, HostPort{Host: "cache.internal", Port: 6379}, actual.Redis)
+		assert.Equal(t, []HostPort{{Host: "a.internal", Port: 7000}, {Host: "b.internal", Port: 7001}}, actual.Peers)
+		if assert.NotNil(t, actual.Endpoint) {
+			assert.Equal(t, "api.example.com", actual.Endpoint.Host)
+		}
+	})
+	t.Run("preset port takes priority over the tag", func(t *testing.T) {
+		env := &envMock{mock: map[string]string{"REDIS": "cache.internal"}}
+		actual := &networkConfigMock{Redis: HostPort{Host: "localhost", Port: 6380}}
+		err := NewWithEnvReader(env).Unmarshall(actual)
+		assert.NoError(t, err)
+		assert.Equal(t, HostPort{Host: "cache.internal", Port: 6380}, actual.Redis)
+	})
+	t.Run("not set", func(t *testing.T) {
+		actual := &networkConfigMock{}
+		err := NewWithEnvReader(&envMock{}).Unmarshall(actual)
+		assert.NoError(t, err)
+		assert.Equal(t, networkConfigMock{}, *actual)
+	})
+}
+
+func TestEnv_UnmarshallNetworkErrors(t *testing.T) {
+	cases := map[string]struct {
+		env      map[string]string
+		expected StructEnvPath
+		message  string
+	}{
+		"ip": {
+			env:      map[string]string{"IP": "10.0.0"},
+			expected: StructEnvPath{StructPath: "IP", EnvPath: "IP"},
+			message:  "'10.0.0' is not a valid IP address",
+		},
+		"cidr": {
+			env:      map[string]string{"Subnet": "10.0.0.0"},
+			expected: StructEnvPath{StructPath: "Subnet", EnvPath: "Subnet"},
+			message:  "'10.0.0.0' is not a valid CIDR, such as 10.0.0.0/8",
+		},
+		"addr port without port": {
+			env:      map[string]string{"Listen": "::1"},
+			expected: StructEnvPath{StructPath: "Listen", EnvPath: "Listen"},
+		},
+		"host port": {
+			env:      map[string]string{"REDIS": "cache:redis"},
+			expected: StructEnvPath{StructPath: "Redis", EnvPath: "REDIS"},
+			message:  "'redis' is not a valid port",
+		},
+		"relative url": {
+			env:      map[string]string{"ENDPOINT": "api.example.com"},
+			expected: StructEnvPath{StructPath: "Endpoint", EnvPath: "ENDPOINT"},
+			message:  "'api.example.com' is not an absolute URL, such as https://example.com",
+		},
+		"scheme": {
+			env:      map[string]string{"ENDPOINT": "ftp://files.example.com"},
+			expected: StructEnvPath{StructPath: "Endpoint", EnvPath: "ENDPOINT"},
+			message:  "the scheme 'ftp' is not one of http, https",
+		},
+		"list element": {
+			env:      map[string]string{"PEERS": "a.internal,b.internal:x"},
+			expected: StructEnvPath{StructPath: "Peers[1]", EnvPath: "PEERS"},
+			message:  "'x' is not a valid port",
+		},
+	}
+
+	for caseName, c := range cases {
+		t.Run(caseName, func(t *testing.T) {
+			err := NewWithEnvReader(&envMock{mock: c.env}).Unmarshall(&networkConfigMock{})
+			if assert.IsType(t, &ParseError{}, err) {
+				assert.Equal(t, c.expected, err.(*ParseError).Path)
+				if c.message != "" {
+					assert.Contains(t, err.Error(), c.message)
+				}
+			}
+		})
+	}
+}
+
+func TestEnv_UnmarshallURLAnyScheme(t *testing.T) {
+	actual := &struct{ Proxy url.URL }{}
+	err := NewWithEnvReader(&envMock{mock: map[string]string{"Proxy": "socks5://proxy:1080"}}).Unmarshall(actual)
+	assert.NoError(t, err)
+	assert.Equal(t, "socks5", actual.Proxy.Scheme)
+}
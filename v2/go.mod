@@ -1,11 +1,17 @@
 module github.com/wojnosystems/go-env/v2
 
-go 1.14
+go 1.18
 
 require (
 	github.com/stretchr/testify v1.6.1
 	github.com/wojnosystems/go-into-struct v0.2.1
-	github.com/wojnosystems/go-optional/v2 v2.0.1
 	github.com/wojnosystems/go-optional-parse-registry/v2 v2.0.0
+	github.com/wojnosystems/go-optional/v2 v2.0.1
 	github.com/wojnosystems/go-parse-register v1.2.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
 )
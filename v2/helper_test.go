@@ -3,7 +3,14 @@ package v2
 // Defines a set of objects used with testing
 
 import (
+	"fmt"
+	into_struct "github.com/wojnosystems/go-into-struct"
 	"github.com/wojnosystems/go-optional/v2"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
 )
 
 type appConfigMock struct {
@@ -16,7 +23,7 @@ func (m appConfigMock) IsEqual(o *appConfigMock) bool {
 	if o == nil {
 		return false
 	}
-	if !m.Name.IsEqual(o.Name) || !m.ThreadCount.IsEqual(o.ThreadCount) {
+	if m.Name != o.Name || m.ThreadCount != o.ThreadCount {
 		return false
 	}
 	if len(m.Databases) != len(o.Databases) {
@@ -41,7 +48,7 @@ func (m dbConfigMock) IsEqual(o *dbConfigMock) bool {
 	if o == nil {
 		return false
 	}
-	return m.Host.IsEqual(o.Host) && m.User.IsEqual(o.User) && m.Password.IsEqual(o.Password) && m.Nested.IsEqual(&o.Nested)
+	return m.Host == o.Host && m.User == o.User && m.Password == o.Password && m.Nested.IsEqual(&o.Nested)
 }
 
 type nestedDbConfigMock struct {
@@ -52,5 +59,198 @@ func (m nestedDbConfigMock) IsEqual(o *nestedDbConfigMock) bool {
 	if o == nil {
 		return false
 	}
-	return m.ConnTimeout.IsEqual(o.ConnTimeout)
+	return m.ConnTimeout == o.ConnTimeout
+}
+
+type setReceiverMockCall struct {
+	StructPath string
+	EnvName    string
+	Value      string
+}
+
+// setReceiverMock records every call to ReceiveSet
+type setReceiverMock struct {
+	calls []setReceiverMockCall
+}
+
+func (s *setReceiverMock) ReceiveSet(structPath into_struct.Path, envName string, value string) {
+	s.calls = append(s.calls, setReceiverMockCall{
+		StructPath: structPath.String(),
+		EnvName:    envName,
+		Value:      value,
+	})
+}
+
+// deprecationReceiverMock records every call to ReceiveSet and ReceiveDeprecated
+type deprecationReceiverMock struct {
+	setReceiverMock
+	deprecated []setReceiverMockCall
+}
+
+func (d *deprecationReceiverMock) ReceiveDeprecated(structPath into_struct.Path, deprecatedEnvName string, preferredEnvName string) {
+	d.deprecated = append(d.deprecated, setReceiverMockCall{
+		StructPath: structPath.String(),
+		EnvName:    deprecatedEnvName,
+		Value:      preferredEnvName,
+	})
+}
+
+type aliasedConfigMock struct {
+	Host     optional.String `env:"DATABASE_HOST,alias=DB_SERVER,deprecated=DB_HOST,deprecated=HOST"`
+	Replicas []struct {
+		Port optional.Int `env:"PORT,deprecated=DB_PORT"`
+	} `env:"REPLICA,deprecated=SLAVE"`
+}
+
+type namedDatabasesMock struct {
+	Name      optional.String
+	Databases map[string]dbConfigMock `env:"DB,alias=DATABASE"`
+}
+
+type redisConfigMock struct {
+	Host optional.String
+	Port optional.Int `env:"PORT,default=6379"`
+}
+
+type pointerConfigMock struct {
+	Name     *string
+	Threads  *int `env:",default=4"`
+	Redis    *redisConfigMock
+	Replicas []*dbConfigMock
+}
+
+// levelMock can only be parsed with UnmarshalText
+type levelMock int
+
+func (l *levelMock) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "debug":
+		*l = 1
+	case "info":
+		*l = 2
+	default:
+		return fmt.Errorf("unknown level '%s'", text)
+	}
+	return nil
+}
+
+// idMock is a structure that can only be parsed with UnmarshalText
+type idMock struct {
+	value string
+}
+
+func (i *idMock) UnmarshalText(text []byte) error {
+	i.value = strings.ToLower(string(text))
+	return nil
+}
+
+// listMock is a slice that is parsed as a whole with UnmarshalText, rather than one element at a time
+type listMock []string
+
+func (l *listMock) UnmarshalText(text []byte) error {
+	*l = strings.Split(string(text), ",")
+	return nil
+}
+
+type textConfigMock struct {
+	Level    levelMock
+	ID       idMock
+	Levels   []levelMock
+	Optional *levelMock
+	List     listMock
+	Unset    levelMock
+}
+
+type networkConfigMock struct {
+	IP       net.IP
+	Addr     netip.Addr
+	Subnet   *net.IPNet
+	Prefix   netip.Prefix
+	Listen   netip.AddrPort
+	Redis    HostPort   `env:"REDIS,port=6379"`
+	Peers    []HostPort `env:"PEERS,port=7000"`
+	Endpoint *url.URL   `env:"ENDPOINT,schemes=http|https"`
+}
+
+type listConfigMock struct {
+	Origins []string `env:"ORIGINS"`
+	Ports   []int    `env:"PORTS,sep=;"`
+	Hosts   []string `env:"HOSTS,default=a|b,sep=|"`
+}
+
+type matrixConfigMock struct {
+	Matrix [][]int
+	Cube   [][][]string `env:"CUBE"`
+	Groups [][]redisConfigMock
+}
+
+type labelsConfigMock struct {
+	Labels map[string]string `env:"LABELS"`
+	Limits map[string]int    `env:"LIMITS,sep=;"`
+}
+
+type prefixedConfigMock struct {
+	Primary dbConfigMock `envPrefix:"PRIMARY"`
+	Replica dbConfigMock `env:"DB" envPrefix:"REPLICA"`
+}
+
+type taggedConfigMock struct {
+	Port      optional.Int    `env:"PORT,default=8080"`
+	Host      optional.String `env:"HOST,required"`
+	Password  optional.String `env:"PASSWORD,secret"`
+	Databases []dbConfigMock  `env:"DB,required"`
+}
+
+type invalidTagConfigMock struct {
+	Databases []struct {
+		Host optional.String `env:"HOST,bogus"`
+	}
+}
+
+type TLSConfigMock struct {
+	CertFile optional.String
+	KeyFile  optional.String
+}
+
+type httpServerMock struct {
+	TLSConfigMock
+	TLSConfig TLSConfigMock `env:"TLS"`
+	Shared    TLSConfigMock `env:",inline"`
+	Port      optional.Int
+}
+
+type unexportedTLSConfigMock struct {
+	CertFile optional.String
+}
+
+// unexportedEmbeddedMock embeds a structure of an unexported type, which is skipped
+type unexportedEmbeddedMock struct {
+	unexportedTLSConfigMock
+	Port optional.Int
+}
+
+type embeddedServerMock struct {
+	Name optional.String
+	TLSConfigMock
+}
+
+type inlineServersMock struct {
+	HTTP embeddedServerMock
+	GRPC embeddedServerMock
+}
+
+type inlineCollisionMock struct {
+	CertFile optional.String
+	TLS      TLSConfigMock `env:",inline"`
+}
+
+type runtimeStateMock struct {
+	Name       optional.String
+	Cache      map[string]string `env:"-"`
+	Computed   dbConfigMock      `env:"-"`
+	Skipped    []dbConfigMock    `env:"-"`
+	Callback   func()            `env:"-"`
+	Dash       optional.String   `env:"-,"`
+	mutex      sync.Mutex
+	unexported optional.String
 }
@@ -10,11 +10,26 @@ type EnvReader interface {
 	Keys(prefix string) []string
 }
 
+// EnvLookuper is implemented by EnvReaders that can tell a variable set to an empty value apart from an unset variable
+type EnvLookuper interface {
+	// Lookup the value of a single environment with the name envNamed. ok is false if the variable is not set at all
+	Lookup(envNamed string) (value string, ok bool)
+}
+
 type SetReceiver interface {
 	// Receive the notice that a value was parsed and set at the fullPath in the destination structure
 	// This will allow the flick library to know which values were updated from which source.
 	// structPath where the value was set in the structure in go. base.value[index].othervalue = value
+	// For values inside maps and pointers to structures, structPath is the path to the map or pointer field
 	// envName is the environment variable used to look up the value
 	// value is what was read from the environment for the envName key
 	ReceiveSet(structPath into_struct.Path, envName string, value string)
 }
+
+// DeprecationReceiver is optionally implemented by a SetReceiver to be told when a value was read from a deprecated
+// variable name, so that configurations still using old names can be found and updated
+type DeprecationReceiver interface {
+	// ReceiveDeprecated is called after ReceiveSet when deprecatedEnvName provided the value at structPath.
+	// preferredEnvName is the name that should be used instead
+	ReceiveDeprecated(structPath into_struct.Path, deprecatedEnvName string, preferredEnvName string)
+}
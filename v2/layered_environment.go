@@ -0,0 +1,91 @@
+package v2
+
+import "sort"
+
+// LayeredEnv reads environment variables from several EnvReaders at once.
+// Layers earlier in the list take precedence over later layers. For example, to have the process environment
+// override an environment-specific file, which in turn overrides a defaults file:
+//
+//	NewLayeredEnv(&OsEnv{}, productionFile, defaultsFile)
+type LayeredEnv struct {
+	layers []EnvReader
+}
+
+// NewLayeredEnv creates a reader that looks up each variable in layers, in order, and uses the first layer that has it set
+func NewLayeredEnv(layers ...EnvReader) *LayeredEnv {
+	return &LayeredEnv{
+		layers: layers,
+	}
+}
+
+func (l *LayeredEnv) Get(envNamed string) string {
+	value, _ := l.Lookup(envNamed)
+	return value
+}
+
+func (l *LayeredEnv) Lookup(envNamed string) (value string, ok bool) {
+	_, value, ok = l.lookupLayer(envNamed)
+	return
+}
+
+// lookupLayer finds the first layer with envNamed set and returns its index and value
+func (l *LayeredEnv) lookupLayer(envNamed string) (layer int, value string, ok bool) {
+	for i, reader := range l.layers {
+		value, ok = LookupEnv(reader, envNamed)
+		if ok {
+			return i, value, ok
+		}
+	}
+	return -1, "", false
+}
+
+// Keys returns the keys from every layer that begin with prefix. Each key appears once, even if set in several layers
+func (l *LayeredEnv) Keys(prefix string) (out []string) {
+	seen := make(map[string]bool)
+	out = []string{}
+	for _, reader := range l.layers {
+		for _, key := range reader.Keys(prefix) {
+			if !seen[key] {
+				seen[key] = true
+				out = append(out, key)
+			}
+		}
+	}
+	sort.Strings(out)
+	return
+}
+
+// ShadowedEnv describes a variable that is set in more than one layer
+type ShadowedEnv struct {
+	// Key is the name of the environment variable
+	Key string
+	// Layer is the index of the layer whose value is used
+	Layer int
+	// ShadowedLayers are the indexes of the lower-precedence layers that also set Key, but whose values are ignored
+	ShadowedLayers []int
+}
+
+// Shadowed reports every variable that is set in more than one layer, sorted by key.
+// This is useful to find defaults that are always overridden and may be stale.
+func (l *LayeredEnv) Shadowed() (out []ShadowedEnv) {
+	out = []ShadowedEnv{}
+	for _, key := range l.Keys("") {
+		layer, _, ok := l.lookupLayer(key)
+		if !ok {
+			continue
+		}
+		shadowed := ShadowedEnv{
+			Key:   key,
+			Layer: layer,
+		}
+		for i := layer + 1; i < len(l.layers); i++ {
+			if _, isSet := LookupEnv(l.layers[i], key); isSet {
+				shadowed.ShadowedLayers = append(shadowed.ShadowedLayers, i)
+			}
+		}
+		if len(shadowed.ShadowedLayers) != 0 {
+			out = append(out, shadowed)
+		}
+	}
+	return
+}
@@ -0,0 +1,104 @@
+package v2
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wojnosystems/go-optional/v2"
+	"testing"
+)
+
+func TestLayeredEnv_Get(t *testing.T) {
+	reader := NewLayeredEnv(
+		&envMock{mock: map[string]string{"Name": "env"}},
+		&envMock{mock: map[string]string{"Name": "production", "ThreadCount": "8", "Empty": ""}},
+		&envMock{mock: map[string]string{"Name": "default", "ThreadCount": "1", "Empty": "default", "Databases_0_Host": "localhost"}},
+	)
+	cases := map[string]struct {
+		key        string
+		expected   string
+		expectedOk bool
+	}{
+		"highest layer wins": {
+			key:        "Name",
+			expected:   "env",
+			expectedOk: true,
+		},
+		"middle layer": {
+			key:        "ThreadCount",
+			expected:   "8",
+			expectedOk: true,
+		},
+		"empty value shadows lower layers": {
+			key:        "Empty",
+			expected:   "",
+			expectedOk: true,
+		},
+		"lowest layer": {
+			key:        "Databases_0_Host",
+			expected:   "localhost",
+			expectedOk: true,
+		},
+		"missing": {
+			key: "Missing",
+		},
+	}
+
+	for caseName, c := range cases {
+		t.Run(caseName, func(t *testing.T) {
+			actual, ok := reader.Lookup(c.key)
+			assert.Equal(t, c.expected, actual)
+			assert.Equal(t, c.expectedOk, ok)
+			assert.Equal(t, c.expected, reader.Get(c.key))
+		})
+	}
+}
+
+func TestLayeredEnv_Keys(t *testing.T) {
+	reader := NewLayeredEnv(
+		&envMock{mock: map[string]string{"Databases_1_Host": "a", "Name": "b"}},
+		&envMock{mock: map[string]string{"Databases_1_Host": "c", "Databases_0_Host": "d"}},
+	)
+	assert.Equal(t, []string{"Databases_0_Host", "Databases_1_Host"}, reader.Keys("Databases_"))
+	assert.Equal(t, []string{}, reader.Keys("Missing"))
+}
+
+func TestLayeredEnv_KeysOsEnv(t *testing.T) {
+	t.Setenv("GOENVTEST_Host", "env")
+	reader := NewLayeredEnv(
+		&OsEnv{},
+		&envMock{mock: map[string]string{"GOENVTEST_Host": "file", "GOENVTEST_Port": "80"}},
+	)
+	assert.Equal(t, []string{"GOENVTEST_Host", "GOENVTEST_Port"}, reader.Keys("GOENVTEST_"))
+	assert.Equal(t, []ShadowedEnv{
+		{Key: "GOENVTEST_Host", Layer: 0, ShadowedLayers: []int{1}},
+	}, reader.Shadowed())
+}
+
+func TestLayeredEnv_Shadowed(t *testing.T) {
+	reader := NewLayeredEnv(
+		&envMock{mock: map[string]string{"Name": "env"}},
+		&envMock{mock: map[string]string{"Name": "production", "ThreadCount": "8"}},
+		&envMock{mock: map[string]string{"Name": "default", "ThreadCount": "1", "Databases_0_Host": "localhost"}},
+	)
+	assert.Equal(t, []ShadowedEnv{
+		{Key: "Name", Layer: 0, ShadowedLayers: []int{1, 2}},
+		{Key: "ThreadCount", Layer: 1, ShadowedLayers: []int{2}},
+	}, reader.Shadowed())
+}
+
+func TestLayeredEnv_Unmarshall(t *testing.T) {
+	reader := NewLayeredEnv(
+		&envMock{mock: map[string]string{"Databases_2_Host": "override"}},
+		&envMock{mock: map[string]string{"Databases_0_Host": "default", "Name": "default"}},
+	)
+	actual := &appConfigMock{}
+	err := NewWithEnvReader(reader).Unmarshall(actual)
+	assert.NoError(t, err)
+	assert.True(t, appConfigMock{
+		Name: optional.StringFrom("default"),
+		Databases: []dbConfigMock{
+			{Host: optional.StringFrom("default")},
+			{},
+			{Host: optional.StringFrom("override")},
+		},
+	}.IsEqual(actual))
+}
@@ -0,0 +1,167 @@
+package v2
+
+import (
+	"errors"
+	into_struct "github.com/wojnosystems/go-into-struct"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// sliceValues describes how the elements of a slice are set: by variables with indexes, or by a delimited list
+// in the variable named after the slice itself
+type sliceValues struct {
+	// envPath is the preferred name of the slice
+	envPath string
+	length  int
+	// items are the elements of a delimited list, or nil when the elements are set by variables with indexes
+	items []string
+	// source is where the delimited list was read from
+	source envValueSource
+}
+
+// readSlice finds how the elements of the slice at parts, or containing the element at parts, are set.
+// Lists are only read for slices of values, such as []string, not slices of structures.
+// A list and variables with indexes cannot both be set, but a default list is only used when no variables with
+// indexes are set
+func (e *envInternal) readSlice(parts []into_struct.PathParter, elemT reflect.Type) (values sliceValues, err error) {
+	tag, err := e.fieldTag(parts)
+	if err != nil {
+		return
+	}
+	candidates, err := e.partsToEnvPaths(parts, false)
+	if err != nil {
+		return
+	}
+	values.envPath = candidates[0].name
+	values.length, err = e.indexedLength(parts, candidates)
+	if err != nil || !e.isParseable(reflect.New(elemT).Interface()) {
+		return
+	}
+	envValue, source, err := e.fieldValue(parts, candidates, tag)
+	if err != nil || envValue == "" {
+		return
+	}
+	if values.length != 0 {
+		if !source.isDefault {
+			err = newParseError(e.structPathString(parts), source.envName, ErrListAndIndexed)
+		}
+		return
+	}
+	separator := e.listSeparatorFor(tag)
+	if separator == "" {
+		// lists are disabled
+		return
+	}
+	values.items, err = splitList(envValue, separator)
+	if err != nil {
+		err = newParseError(e.structPathString(parts), source.envName, err)
+		return
+	}
+	values.length = len(values.items)
+	values.source = source
+	return
+}
+
+// setNestedSlice fills the slice at structFullPath, an element of a slice of slices, such as Matrix[0] of a [][]int.
+// into_struct replaces the outer element in the path while it fills the inner slice, which loses the outer index, so the
+// inner slice is filled here instead. It becomes the only field of a structure named after the outer element, Matrix_0,
+// so its elements are read from Matrix_0_1_ or a list in Matrix_0, like any other slice. The outer slice's tag options
+// apply to the inner one
+func (e *envInternal) setNestedSlice(structFullPath into_struct.Path) (err error) {
+	parts := structFullPath.Parts()
+	tag, err := e.fieldTag(parts)
+	if err != nil {
+		return
+	}
+	roots, err := e.partsToEnvPaths(parts, true)
+	if err != nil {
+		return
+	}
+	elementTag := tag.forElement()
+	elementTag.absolute = false
+	element := structFullPath.Top().Value()
+	holder := reflect.New(reflect.StructOf([]reflect.StructField{{Name: "Elements", Type: element.Type()}}))
+	holder.Elem().Field(0).Set(element)
+	_, err = e.unmarshallNested(structFullPath, e.structPathString(parts), roots, true, &elementTag, holder)
+	if err != nil {
+		return
+	}
+	element.Set(holder.Elem().Field(0))
+	return
+}
+
+// listSeparatorFor is the separator between the items of lists in fields with tag. Blank when lists are disabled
+func (e *envInternal) listSeparatorFor(tag envTag) string {
+	if tag.separator != "" {
+		return tag.separator
+	}
+	return e.listSeparator
+}
+
+// setListElement parses the element at index of a delimited list into the slice element at structFullPath
+func (e *envInternal) setListElement(structFullPath into_struct.Path, values sliceValues, index int) (handled bool, err error) {
+	handled = true
+	if values.items[index] == "" {
+		return
+	}
+	_, err = e.parseValue(structFullPath, values.envPath, values.items[index], values.source, structFullPath.Top().Value().Addr().Interface())
+	return
+}
+
+var errUnterminatedQuote = errors.New("a quote is not closed")
+
+// splitList splits value on separator. Whitespace around each item is removed. Items can be surrounded with
+// double quotes to keep the separator and whitespace, and a backslash includes the next character as it is:
+//
+//	a, "b, c", d\,e  is  [a] [b, c] [d,e]
+func splitList(value string, separator string) (items []string, err error) {
+	var item []listRune
+	quoted := false
+	for i := 0; i < len(value); {
+		switch {
+		case value[i] == '\\' && i+1 < len(value):
+			r, size := utf8.DecodeRuneInString(value[i+1:])
+			item = append(item, listRune{r: r, literal: true})
+			i += 1 + size
+		case value[i] == '"':
+			quoted = !quoted
+			i++
+		case !quoted && strings.HasPrefix(value[i:], separator):
+			items = append(items, trimListItem(item))
+			item = item[:0]
+			i += len(separator)
+		default:
+			r, size := utf8.DecodeRuneInString(value[i:])
+			item = append(item, listRune{r: r, literal: quoted})
+			i += size
+		}
+	}
+	if quoted {
+		return nil, errUnterminatedQuote
+	}
+	return append(items, trimListItem(item)), nil
+}
+
+// listRune is a character of a list item. literal characters were quoted or escaped, and are never trimmed
+type listRune struct {
+	r       rune
+	literal bool
+}
+
+// trimListItem removes whitespace that is not literal from both ends of item
+func trimListItem(item []listRune) string {
+	start, end := 0, len(item)
+	for start < end && !item[start].literal && unicode.IsSpace(item[start].r) {
+		start++
+	}
+	for end > start && !item[end-1].literal && unicode.IsSpace(item[end-1].r) {
+		end--
+	}
+	sb := strings.Builder{}
+	for _, r := range item[start:end] {
+		sb.WriteRune(r.r)
+	}
+	return sb.String()
+}
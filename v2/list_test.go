@@ -0,0 +1,68 @@
+package v2
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSplitList(t *testing.T) {
+	cases := map[string]struct {
+		value     string
+		separator string
+		expected  []string
+	}{
+		"single": {
+			value:     "a",
+			separator: ",",
+			expected:  []string{"a"},
+		},
+		"several": {
+			value:     "a,b,c",
+			separator: ",",
+			expected:  []string{"a", "b", "c"},
+		},
+		"whitespace is trimmed": {
+			value:     " a ,\tb\n, c d ",
+			separator: ",",
+			expected:  []string{"a", "b", "c d"},
+		},
+		"empty items": {
+			value:     "a,,b,",
+			separator: ",",
+			expected:  []string{"a", "", "b", ""},
+		},
+		"quotes": {
+			value:     `"a,b", " c ",d"e,f"`,
+			separator: ",",
+			expected:  []string{"a,b", " c ", "de,f"},
+		},
+		"escapes": {
+			value:     `a\,b,\"c\\, d\ `,
+			separator: ",",
+			expected:  []string{"a,b", `"c\`, "d "},
+		},
+		"trailing backslash": {
+			value:     `a\`,
+			separator: ",",
+			expected:  []string{`a\`},
+		},
+		"long separator": {
+			value:     "a::b:c",
+			separator: "::",
+			expected:  []string{"a", "b:c"},
+		},
+	}
+
+	for caseName, c := range cases {
+		t.Run(caseName, func(t *testing.T) {
+			actual, err := splitList(c.value, c.separator)
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, actual)
+		})
+	}
+}
+
+func TestSplitListUnterminatedQuote(t *testing.T) {
+	_, err := splitList(`a,"b`, ",")
+	assert.EqualError(t, err, "a quote is not closed")
+}
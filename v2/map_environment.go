@@ -0,0 +1,81 @@
+package v2
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// MapEnv reads environment variables from memory. Useful for tests, or to freeze the process environment so that
+// calls to os.Setenv while unmarshalling cannot change the result
+type MapEnv struct {
+	values map[string]string
+	keys   []string
+}
+
+// NewMapEnv creates a reader with a copy of values. Changes to values after this call are not seen by the reader
+func NewMapEnv(values map[string]string) *MapEnv {
+	m := &MapEnv{
+		values: make(map[string]string, len(values)),
+	}
+	for key, value := range values {
+		m.values[key] = value
+	}
+	m.sortKeys()
+	return m
+}
+
+// NewMapEnvFromEnviron creates a reader from KEY=VALUE strings, in the format returned by os.Environ.
+// Entries without an equal sign are ignored. If a key appears more than once, the last value wins
+func NewMapEnvFromEnviron(environ []string) *MapEnv {
+	m := &MapEnv{
+		values: make(map[string]string, len(environ)),
+	}
+	for _, entry := range environ {
+		key, value, ok := splitEnvironEntry(entry)
+		if ok {
+			m.values[key] = value
+		}
+	}
+	m.sortKeys()
+	return m
+}
+
+// NewMapEnvSnapshot creates a reader with a copy of the current process environment
+func NewMapEnvSnapshot() *MapEnv {
+	return NewMapEnvFromEnviron(os.Environ())
+}
+
+func (m *MapEnv) Get(envNamed string) string {
+	return m.values[envNamed]
+}
+
+func (m *MapEnv) Lookup(envNamed string) (value string, ok bool) {
+	value, ok = m.values[envNamed]
+	return
+}
+
+func (m *MapEnv) Keys(prefix string) (out []string) {
+	return SelectKeysWithPrefix(m.keys, prefix)
+}
+
+func (m *MapEnv) sortKeys() {
+	m.keys = make([]string, 0, len(m.values))
+	for key := range m.values {
+		m.keys = append(m.keys, key)
+	}
+	sort.Strings(m.keys)
+}
+
+// splitEnvironEntry splits KEY=VALUE at the first equal sign. The equal sign search starts at the second character
+// because Windows uses variables such as "=C:=C:\" to track the working directory of each drive
+func splitEnvironEntry(entry string) (key string, value string, ok bool) {
+	if entry == "" {
+		return
+	}
+	i := strings.IndexByte(entry[1:], '=')
+	if i == -1 {
+		return
+	}
+	return entry[:i+1], entry[i+2:], true
+}
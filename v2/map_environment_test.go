@@ -0,0 +1,69 @@
+package v2
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestNewMapEnvFromEnviron(t *testing.T) {
+	cases := map[string]struct {
+		environ  []string
+		expected map[string]string
+	}{
+		"empty": {
+			expected: map[string]string{},
+		},
+		"values": {
+			environ:  []string{"A=1", "B=", "C=x=y"},
+			expected: map[string]string{"A": "1", "B": "", "C": "x=y"},
+		},
+		"last wins": {
+			environ:  []string{"A=1", "A=2"},
+			expected: map[string]string{"A": "2"},
+		},
+		"malformed entries ignored": {
+			environ:  []string{"", "NOEQUALS", "A=1"},
+			expected: map[string]string{"A": "1"},
+		},
+		"windows drive variables": {
+			environ:  []string{`=C:=C:\Users`},
+			expected: map[string]string{"=C:": `C:\Users`},
+		},
+	}
+
+	for caseName, c := range cases {
+		t.Run(caseName, func(t *testing.T) {
+			reader := NewMapEnvFromEnviron(c.environ)
+			actual := make(map[string]string)
+			for _, key := range reader.Keys("") {
+				value, ok := reader.Lookup(key)
+				assert.True(t, ok)
+				actual[key] = value
+			}
+			assert.Equal(t, c.expected, actual)
+		})
+	}
+}
+
+func TestNewMapEnv(t *testing.T) {
+	values := map[string]string{"Databases_1_Host": "a", "Databases_0_Host": "b", "Name": "c"}
+	reader := NewMapEnv(values)
+	values["Name"] = "changed"
+	assert.Equal(t, "c", reader.Get("Name"))
+	assert.Equal(t, []string{"Databases_0_Host", "Databases_1_Host"}, reader.Keys("Databases_"))
+	_, ok := reader.Lookup("Missing")
+	assert.False(t, ok)
+}
+
+func TestNewMapEnvSnapshot(t *testing.T) {
+	const key = "GO_ENV_TEST_SNAPSHOT"
+	assert.NoError(t, os.Setenv(key, "before"))
+	defer func() {
+		_ = os.Unsetenv(key)
+	}()
+	reader := NewMapEnvSnapshot()
+	assert.NoError(t, os.Setenv(key, "after"))
+	assert.Equal(t, "before", reader.Get(key))
+	assert.Equal(t, []string{key}, reader.Keys(key))
+}
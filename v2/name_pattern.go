@@ -0,0 +1,164 @@
+package v2
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// envAnyPlaceholder stands in for the rest of a name in the names a slice reads to find its length
+	envAnyPlaceholder = "{*}"
+	// envKeyPlaceholder stands in for map keys in names that are computed from types rather than values
+	envKeyPlaceholder = "{K}"
+)
+
+// namePatternToken is a single character of a name, or a placeholder that matches many characters
+type namePatternToken struct {
+	kind namePatternKind
+	// r is the character matched by namePatternRune, or the character not matched by namePatternKey
+	r rune
+}
+
+type namePatternKind int
+
+const (
+	// namePatternRune matches r
+	namePatternRune namePatternKind = iota
+	// namePatternDigit matches a single digit
+	namePatternDigit
+	// namePatternDigits matches any number of digits, including none
+	namePatternDigits
+	// namePatternKey matches a single character other than r
+	namePatternKey
+	// namePatternKeys matches any number of characters other than r, including none
+	namePatternKeys
+	// namePatternAny matches anything, including nothing
+	namePatternAny
+)
+
+// namePattern is a name computed from a type rather than values. Slice indexes, written as envIndexPlaceholder,
+// match any number. Map keys, written as envKeyPlaceholder, match anything without the Field separator.
+// envAnyPlaceholder matches anything
+type namePattern []namePatternToken
+
+func (s Separators) namePattern(name string) (pattern namePattern) {
+	keyExcludes, _ := utf8.DecodeRuneInString(s.Field)
+	for name != "" {
+		switch {
+		case strings.HasPrefix(name, envIndexPlaceholder):
+			pattern = append(pattern, namePatternToken{kind: namePatternDigit}, namePatternToken{kind: namePatternDigits})
+			name = name[len(envIndexPlaceholder):]
+		case strings.HasPrefix(name, envKeyPlaceholder):
+			pattern = append(pattern, namePatternToken{kind: namePatternKey, r: keyExcludes}, namePatternToken{kind: namePatternKeys, r: keyExcludes})
+			name = name[len(envKeyPlaceholder):]
+		case strings.HasPrefix(name, envAnyPlaceholder):
+			pattern = append(pattern, namePatternToken{kind: namePatternAny})
+			name = name[len(envAnyPlaceholder):]
+		default:
+			r, size := utf8.DecodeRuneInString(name)
+			pattern = append(pattern, namePatternToken{kind: namePatternRune, r: r})
+			name = name[size:]
+		}
+	}
+	return
+}
+
+// repeats is true for tokens that can match any number of characters, including none
+func (t namePatternToken) repeats() bool {
+	return t.kind == namePatternDigits || t.kind == namePatternKeys || t.kind == namePatternAny
+}
+
+// matches is true when the token matches r
+func (t namePatternToken) matches(r rune, caseInsensitive bool) bool {
+	switch t.kind {
+	case namePatternRune:
+		return t.r == r || (caseInsensitive && strings.EqualFold(string(t.r), string(r)))
+	case namePatternDigit, namePatternDigits:
+		return isDigit(r)
+	case namePatternKey, namePatternKeys:
+		return t.r != r
+	}
+	return true
+}
+
+// patternOverlap finds a name matched by both a and b, by walking both patterns at the same time
+type patternOverlap struct {
+	a, b            namePattern
+	caseInsensitive bool
+	visited         map[[2]int]bool
+	example         []rune
+}
+
+// namePatternsOverlap returns an example of a name matched by both a and b, if there is one
+func namePatternsOverlap(a namePattern, b namePattern, caseInsensitive bool) (example string, overlap bool) {
+	o := &patternOverlap{
+		a:               a,
+		b:               b,
+		caseInsensitive: caseInsensitive,
+		visited:         make(map[[2]int]bool),
+	}
+	if o.visit(0, 0) {
+		return string(o.example), true
+	}
+	return
+}
+
+// visit is true when the rest of a, starting at i, and the rest of b, starting at j, can match the same text
+func (o *patternOverlap) visit(i int, j int) bool {
+	if o.visited[[2]int{i, j}] {
+		return false
+	}
+	o.visited[[2]int{i, j}] = true
+	if i == len(o.a) && j == len(o.b) {
+		return true
+	}
+	if i < len(o.a) && o.a[i].repeats() && o.visit(i+1, j) {
+		return true
+	}
+	if j < len(o.b) && o.b[j].repeats() && o.visit(i, j+1) {
+		return true
+	}
+	if i == len(o.a) || j == len(o.b) {
+		return false
+	}
+	r, ok := o.common(o.a[i], o.b[j])
+	if !ok {
+		return false
+	}
+	o.example = append(o.example, r)
+	if o.visit(o.next(o.a, i), o.next(o.b, j)) {
+		return true
+	}
+	o.example = o.example[:len(o.example)-1]
+	return false
+}
+
+// common finds a character matched by both a and b. Placeholders are shown as 0 or x in examples when possible
+func (o *patternOverlap) common(a namePatternToken, b namePatternToken) (r rune, ok bool) {
+	examples := []rune{'0', 'x', 'y'}
+	if b.kind == namePatternRune {
+		examples = append([]rune{b.r}, examples...)
+	}
+	if a.kind == namePatternRune {
+		examples = append([]rune{a.r}, examples...)
+	}
+	for _, r = range examples {
+		if a.matches(r, o.caseInsensitive) && b.matches(r, o.caseInsensitive) {
+			return r, true
+		}
+	}
+	return
+}
+
+// next is the position in pattern after matching a character with the token at i. Repeating tokens can match again
+func (o *patternOverlap) next(pattern namePattern, i int) int {
+	if pattern[i].repeats() {
+		return i
+	}
+	return i + 1
+}
+
+// isDigit is true for the digits matched by \d in indexRegexp
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
@@ -0,0 +1,86 @@
+package v2
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNamePatternsOverlap(t *testing.T) {
+	cases := map[string]struct {
+		a               string
+		b               string
+		caseInsensitive bool
+		expected        string
+		expectedOverlap bool
+	}{
+		"identical": {
+			a:               "Host",
+			b:               "Host",
+			expected:        "Host",
+			expectedOverlap: true,
+		},
+		"different": {
+			a: "Host",
+			b: "Port",
+		},
+		"prefix only": {
+			a: "Host",
+			b: "Hosts",
+		},
+		"index matches digits": {
+			a:               "Databases_{N}_Host",
+			b:               "Databases_12_Host",
+			expected:        "Databases_12_Host",
+			expectedOverlap: true,
+		},
+		"index does not match letters": {
+			a: "Databases_{N}_Host",
+			b: "Databases_primary_Host",
+		},
+		"index needs a digit": {
+			a: "Databases_{N}_Host",
+			b: "Databases__Host",
+		},
+		"nested indexes": {
+			a: "Matrix_{N}_{N}_",
+			b: "Matrix_{N}_",
+		},
+		"any suffix": {
+			a:               "Databases_{N}_{*}",
+			b:               "Databases_3_Anything",
+			expected:        "Databases_3_Anything",
+			expectedOverlap: true,
+		},
+		"key": {
+			a:               "Databases_{K}_Host",
+			b:               "Databases_primary_Host",
+			expected:        "Databases_primary_Host",
+			expectedOverlap: true,
+		},
+		"key without separator": {
+			a: "Databases_{K}_Host",
+			b: "Databases_us_east_Host",
+		},
+		"case": {
+			a: "HOST",
+			b: "host",
+		},
+		"case insensitive": {
+			a:               "HOST",
+			b:               "host",
+			caseInsensitive: true,
+			expected:        "HOST",
+			expectedOverlap: true,
+		},
+	}
+
+	for caseName, c := range cases {
+		t.Run(caseName, func(t *testing.T) {
+			actual, overlap := namePatternsOverlap(DefaultSeparators.namePattern(c.a), DefaultSeparators.namePattern(c.b), c.caseInsensitive)
+			assert.Equal(t, c.expectedOverlap, overlap)
+			if c.expectedOverlap {
+				assert.Equal(t, c.expected, actual)
+			}
+		})
+	}
+}
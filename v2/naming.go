@@ -0,0 +1,83 @@
+package v2
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NamingStrategy converts the name of a field in a Go structure into the name used for its environment variable.
+// It is only used for fields without an env tag, an env tag always wins
+type NamingStrategy func(fieldName string) string
+
+// IdentityCase uses the name of the field as-is: ConnTimeout stays ConnTimeout. This is the default
+func IdentityCase(fieldName string) string {
+	return fieldName
+}
+
+// ScreamingSnakeCase uppercases the words in the field name and separates them with underscores.
+// Acronyms are kept together: ConnTimeout becomes CONN_TIMEOUT and HTTPPort becomes HTTP_PORT
+func ScreamingSnakeCase(fieldName string) string {
+	return strings.ToUpper(strings.Join(splitWords(fieldName), "_"))
+}
+
+// SnakeCase lowercases the words in the field name and separates them with underscores.
+// Acronyms are kept together: ConnTimeout becomes conn_timeout and HTTPPort becomes http_port
+func SnakeCase(fieldName string) string {
+	return strings.ToLower(strings.Join(splitWords(fieldName), "_"))
+}
+
+// KebabCase lowercases the words in the field name and separates them with dashes: HTTPPort becomes http-port.
+// Most shells cannot set variables with dashes, but they work with other sources, such as NewArgsEnv and NewDirEnv
+func KebabCase(fieldName string) string {
+	return strings.ToLower(strings.Join(splitWords(fieldName), "-"))
+}
+
+// splitWords splits a Go identifier into words. A new word starts:
+// at an uppercase letter following a lowercase letter or digit: Conn|Timeout, Port8080|Alt
+// at the last uppercase letter in a run followed by a lowercase letter: HTTP|Port
+// An uppercase run followed by a single lowercase letter and a digit is a versioned acronym and stays one word:
+// IPv6|Addr, APIv2|Client, TLSv12|Enabled. Words in mixedCaseWords, such as OAuth, are never split
+// Underscores separate words and are removed
+func splitWords(name string) (words []string) {
+	runes := []rune(name)
+	start := 0
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '_' {
+			if i > start {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i + 1
+			continue
+		}
+		if i == start || !unicode.IsUpper(runes[i]) || i < start+mixedCaseWordLength(runes[start:]) {
+			continue
+		}
+		previous := runes[i-1]
+		nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if unicode.IsUpper(previous) && nextIsLower && i+2 < len(runes) && unicode.IsDigit(runes[i+2]) {
+			continue
+		}
+		if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextIsLower) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+	return
+}
+
+// mixedCaseWords are words written with mixed case that cannot be told apart from two words by their letters alone,
+// such as OAuth, which would otherwise split like AValue
+var mixedCaseWords = []string{"OAuth"}
+
+// mixedCaseWordLength is the length of the word from mixedCaseWords that runes start with, or 0 if there is none
+func mixedCaseWordLength(runes []rune) int {
+	for _, word := range mixedCaseWords {
+		if strings.HasPrefix(string(runes), word) {
+			return len([]rune(word))
+		}
+	}
+	return 0
+}
@@ -0,0 +1,43 @@
+package v2
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNamingStrategies(t *testing.T) {
+	cases := map[string]struct {
+		screamingSnake string
+		snake          string
+		kebab          string
+	}{
+		"Name":          {screamingSnake: "NAME", snake: "name", kebab: "name"},
+		"ConnTimeout":   {screamingSnake: "CONN_TIMEOUT", snake: "conn_timeout", kebab: "conn-timeout"},
+		"HTTPPort":      {screamingSnake: "HTTP_PORT", snake: "http_port", kebab: "http-port"},
+		"UserID":        {screamingSnake: "USER_ID", snake: "user_id", kebab: "user-id"},
+		"ID":            {screamingSnake: "ID", snake: "id", kebab: "id"},
+		"Port8080Alt":   {screamingSnake: "PORT8080_ALT", snake: "port8080_alt", kebab: "port8080-alt"},
+		"Already_Snake": {screamingSnake: "ALREADY_SNAKE", snake: "already_snake", kebab: "already-snake"},
+		"useTLS":        {screamingSnake: "USE_TLS", snake: "use_tls", kebab: "use-tls"},
+		"IPv6Addr":      {screamingSnake: "IPV6_ADDR", snake: "ipv6_addr", kebab: "ipv6-addr"},
+		"OAuth2Token":   {screamingSnake: "OAUTH2_TOKEN", snake: "oauth2_token", kebab: "oauth2-token"},
+		"BindIPv4":      {screamingSnake: "BIND_IPV4", snake: "bind_ipv4", kebab: "bind-ipv4"},
+		"HTTPServer2":   {screamingSnake: "HTTP_SERVER2", snake: "http_server2", kebab: "http-server2"},
+		"AValue":        {screamingSnake: "A_VALUE", snake: "a_value", kebab: "a-value"},
+		"AValue2":       {screamingSnake: "A_VALUE2", snake: "a_value2", kebab: "a-value2"},
+		"APIv2Client":   {screamingSnake: "APIV2_CLIENT", snake: "apiv2_client", kebab: "apiv2-client"},
+		"TLSv12Enabled": {screamingSnake: "TLSV12_ENABLED", snake: "tlsv12_enabled", kebab: "tlsv12-enabled"},
+		"HTTPv2Port":    {screamingSnake: "HTTPV2_PORT", snake: "httpv2_port", kebab: "httpv2-port"},
+		"OAuthToken":    {screamingSnake: "OAUTH_TOKEN", snake: "oauth_token", kebab: "oauth-token"},
+		"GoogleOAuth2":  {screamingSnake: "GOOGLE_OAUTH2", snake: "google_oauth2", kebab: "google-oauth2"},
+	}
+
+	for fieldName, c := range cases {
+		t.Run(fieldName, func(t *testing.T) {
+			assert.Equal(t, fieldName, IdentityCase(fieldName))
+			assert.Equal(t, c.screamingSnake, ScreamingSnakeCase(fieldName))
+			assert.Equal(t, c.snake, SnakeCase(fieldName))
+			assert.Equal(t, c.kebab, KebabCase(fieldName))
+		})
+	}
+}
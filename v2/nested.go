@@ -0,0 +1,43 @@
+package v2
+
+import (
+	into_struct "github.com/wojnosystems/go-into-struct"
+	"reflect"
+)
+
+// unmarshallNested fills the structure dst, which is not part of the structure being unmarshalled, such as a map element
+// or the target of a pointer. roots are the names of the structure, and its fields are named after them.
+// inElement is true when dst is within a slice or map element. elementSliceTag is only set by setNestedSlice.
+// Returns the number of values that were set
+func (e *envInternal) unmarshallNested(structFullPath into_struct.Path, structPath string, roots []envNameCandidate, inElement bool, elementSliceTag *envTag, dst reflect.Value) (sets int, err error) {
+	nested := *e
+	nested.roots = roots
+	nested.elementSliceTag = elementSliceTag
+	nested.structPathPrefix = structPath
+	nested.inElement = inElement || e.inElement || containsElement(structFullPath.Parts())
+	receiver := &nestedReceiver{receiver: e.emitter, structPath: structFullPath}
+	nested.emitter = receiver
+	err = into_struct.Unmarshall(dst.Interface(), &nested)
+	return receiver.sets, err
+}
+
+// nestedReceiver passes values set in a nested structure along to the SetReceiver. into_struct.Path cannot name
+// fields outside of the structure being unmarshalled, so the path to the map or pointer is reported instead.
+// The name of the variable identifies the field
+type nestedReceiver struct {
+	receiver   SetReceiver
+	structPath into_struct.Path
+	// sets counts the values set in the nested structure
+	sets int
+}
+
+func (n *nestedReceiver) ReceiveSet(_ into_struct.Path, envName string, value string) {
+	n.sets++
+	n.receiver.ReceiveSet(n.structPath, envName, value)
+}
+
+func (n *nestedReceiver) ReceiveDeprecated(_ into_struct.Path, deprecatedEnvName string, preferredEnvName string) {
+	if receiver, ok := n.receiver.(DeprecationReceiver); ok {
+		receiver.ReceiveDeprecated(n.structPath, deprecatedEnvName, preferredEnvName)
+	}
+}
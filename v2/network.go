@@ -0,0 +1,153 @@
+package v2
+
+import (
+	"errors"
+	"fmt"
+	"github.com/wojnosystems/go-parse-register"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// HostPort is a network endpoint, such as example.com:443, 10.0.0.1:5432 or [::1]:8080. The host may be blank, as in
+// :8080, to listen on every interface. Values without a port keep the Port that is already set. If Port is 0, the port
+// from the field's tag is used: `env:"REDIS,port=6379"`. Without either, the port is required
+type HostPort struct {
+	Host string
+	Port uint16
+}
+
+// String is the endpoint in the form accepted by net.Dial
+func (h HostPort) String() string {
+	return net.JoinHostPort(h.Host, strconv.Itoa(int(h.Port)))
+}
+
+// UnmarshalText parses host:port, or a host on its own if Port is already set
+func (h *HostPort) UnmarshalText(text []byte) error {
+	value := string(text)
+	host, port, err := net.SplitHostPort(value)
+	if err != nil {
+		var addrErr *net.AddrError
+		if !errors.As(err, &addrErr) || !isMissingPort(value, addrErr) {
+			return fmt.Errorf("'%s' is not a valid host:port", value)
+		}
+		if h.Port == 0 {
+			return fmt.Errorf("'%s' does not include a port", value)
+		}
+		h.Host = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+		return nil
+	}
+	number, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return fmt.Errorf("'%s' is not a valid port", port)
+	}
+	h.Host, h.Port = host, uint16(number)
+	return nil
+}
+
+// isMissingPort is true when value is only a host: a name, an IPv4 address, or an IPv6 address with or without brackets
+func isMissingPort(value string, err *net.AddrError) bool {
+	if value == "" {
+		return false
+	}
+	if err.Err == "missing port in address" {
+		return true
+	}
+	_, ipErr := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(value, "["), "]"))
+	return ipErr == nil
+}
+
+// RegisterNetworkTypes adds parsers for net.IP, net.IPNet, netip.Addr, netip.Prefix, netip.AddrPort, url.URL and
+// HostPort to r. Fields of type *net.IPNet and *url.URL are allocated when their variable is set, like other pointers.
+// The default registry already includes these. Returns r to allow chaining
+func RegisterNetworkTypes(r parse_register.RegisterSetter) parse_register.RegisterSetter {
+	r.Register(reflect.TypeOf((*net.IP)(nil)).Elem(), func(settableDst interface{}, src string) (err error) {
+		ip := net.ParseIP(src)
+		if ip == nil {
+			return fmt.Errorf("'%s' is not a valid IP address", src)
+		}
+		*settableDst.(*net.IP) = ip
+		return
+	})
+	r.Register(reflect.TypeOf((*net.IPNet)(nil)).Elem(), func(settableDst interface{}, src string) (err error) {
+		_, network, err := net.ParseCIDR(src)
+		if err != nil {
+			return fmt.Errorf("'%s' is not a valid CIDR, such as 10.0.0.0/8", src)
+		}
+		*settableDst.(*net.IPNet) = *network
+		return
+	})
+	r.Register(reflect.TypeOf((*netip.Addr)(nil)).Elem(), func(settableDst interface{}, src string) (err error) {
+		*settableDst.(*netip.Addr), err = netip.ParseAddr(src)
+		return
+	})
+	r.Register(reflect.TypeOf((*netip.Prefix)(nil)).Elem(), func(settableDst interface{}, src string) (err error) {
+		*settableDst.(*netip.Prefix), err = netip.ParsePrefix(src)
+		return
+	})
+	r.Register(reflect.TypeOf((*netip.AddrPort)(nil)).Elem(), func(settableDst interface{}, src string) (err error) {
+		*settableDst.(*netip.AddrPort), err = netip.ParseAddrPort(src)
+		return
+	})
+	r.Register(reflect.TypeOf((*url.URL)(nil)).Elem(), func(settableDst interface{}, src string) (err error) {
+		u, err := url.Parse(src)
+		if err != nil {
+			return
+		}
+		if !u.IsAbs() {
+			return fmt.Errorf("'%s' is not an absolute URL, such as https://example.com", src)
+		}
+		*settableDst.(*url.URL) = *u
+		return
+	})
+	r.Register(reflect.TypeOf((*HostPort)(nil)).Elem(), func(settableDst interface{}, src string) (err error) {
+		return settableDst.(*HostPort).UnmarshalText([]byte(src))
+	})
+	return r
+}
+
+// networkOptions are the tag options for network values. They apply to every value parsed for the field,
+// including the elements of slices and maps
+type networkOptions struct {
+	// schemes are the schemes allowed in URLs, in any case. Any scheme is allowed if empty
+	schemes []string
+	// defaultPort is the port of HostPort values without one, unless their Port is already set. 0 if there is none
+	defaultPort uint16
+}
+
+var (
+	urlType      = reflect.TypeOf((*url.URL)(nil)).Elem()
+	hostPortType = reflect.TypeOf((*HostPort)(nil)).Elem()
+)
+
+// prepare sets the default port before a value is parsed into valueDst. A Port that is already set takes priority
+func (o networkOptions) prepare(valueDst interface{}) {
+	if hostPort, ok := valueDst.(*HostPort); ok && hostPort.Port == 0 {
+		hostPort.Port = o.defaultPort
+	}
+}
+
+// check validates a value after it was parsed into valueDst
+func (o networkOptions) check(valueDst interface{}) error {
+	u, ok := valueDst.(*url.URL)
+	if !ok || len(o.schemes) == 0 {
+		return nil
+	}
+	for _, scheme := range o.schemes {
+		if strings.EqualFold(scheme, u.Scheme) {
+			return nil
+		}
+	}
+	return fmt.Errorf("the scheme '%s' is not one of %s", u.Scheme, strings.Join(o.schemes, ", "))
+}
+
+// valueType is the type each value of a field is parsed into: the element type of pointers, slices and maps
+func valueType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,83 @@
+package v2
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHostPort_UnmarshalText(t *testing.T) {
+	cases := map[string]struct {
+		value    string
+		preset   HostPort
+		expected HostPort
+		err      string
+	}{
+		"name": {
+			value:    "example.com:443",
+			expected: HostPort{Host: "example.com", Port: 443},
+		},
+		"ipv6": {
+			value:    "[::1]:8080",
+			expected: HostPort{Host: "::1", Port: 8080},
+		},
+		"any interface": {
+			value:    ":8080",
+			expected: HostPort{Port: 8080},
+		},
+		"port replaces the default": {
+			value:    "example.com:8443",
+			preset:   HostPort{Port: 443},
+			expected: HostPort{Host: "example.com", Port: 8443},
+		},
+		"default port": {
+			value:    "example.com",
+			preset:   HostPort{Port: 443},
+			expected: HostPort{Host: "example.com", Port: 443},
+		},
+		"default port ipv6": {
+			value:    "::1",
+			preset:   HostPort{Port: 443},
+			expected: HostPort{Host: "::1", Port: 443},
+		},
+		"default port bracketed ipv6": {
+			value:    "[::1]",
+			preset:   HostPort{Port: 443},
+			expected: HostPort{Host: "::1", Port: 443},
+		},
+		"missing port": {
+			value: "example.com",
+			err:   "'example.com' does not include a port",
+		},
+		"invalid port": {
+			value: "example.com:https",
+			err:   "'https' is not a valid port",
+		},
+		"port out of range": {
+			value: "example.com:65536",
+			err:   "'65536' is not a valid port",
+		},
+		"empty": {
+			value:  "",
+			preset: HostPort{Port: 443},
+			err:    "'' is not a valid host:port",
+		},
+	}
+
+	for caseName, c := range cases {
+		t.Run(caseName, func(t *testing.T) {
+			actual := c.preset
+			err := actual.UnmarshalText([]byte(c.value))
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, actual)
+		})
+	}
+}
+
+func TestHostPort_String(t *testing.T) {
+	assert.Equal(t, "example.com:443", HostPort{Host: "example.com", Port: 443}.String())
+	assert.Equal(t, "[::1]:8080", HostPort{Host: "::1", Port: 8080}.String())
+}
@@ -0,0 +1,37 @@
+package v2
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// NewMapEnvFromNulSeparated creates a reader from KEY=VALUE entries separated by NUL characters.
+// This is the format of /proc/<pid>/environ on Linux and of the output of `env -0`.
+// Entries without an equal sign are ignored. If a key appears more than once, the last value wins
+func NewMapEnvFromNulSeparated(src io.Reader) (reader *MapEnv, err error) {
+	var content bytes.Buffer
+	_, err = content.ReadFrom(src)
+	if err != nil {
+		return
+	}
+	reader = NewMapEnvFromEnviron(strings.Split(content.String(), "\x00"))
+	return
+}
+
+// NewProcessEnv creates a reader with the environment of the running process with the id pid, as it was when that
+// process started. This reads /proc/<pid>/environ, so it is only available on Linux and requires permission to read
+// the other process' environment
+func NewProcessEnv(pid int) (reader *MapEnv, err error) {
+	file, err := os.Open(filepath.Join("/proc", strconv.Itoa(pid), "environ"))
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	return NewMapEnvFromNulSeparated(file)
+}
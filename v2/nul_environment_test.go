@@ -0,0 +1,57 @@
+package v2
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestNewMapEnvFromNulSeparated(t *testing.T) {
+	cases := map[string]struct {
+		content  string
+		expected map[string]string
+	}{
+		"empty": {
+			expected: map[string]string{},
+		},
+		"env -0 output": {
+			content:  "Name=SuperServer\x00Databases_0_Host=example.com\x00",
+			expected: map[string]string{"Name": "SuperServer", "Databases_0_Host": "example.com"},
+		},
+		"values with newlines": {
+			content:  "CERT=line1\nline2\x00EMPTY=",
+			expected: map[string]string{"CERT": "line1\nline2", "EMPTY": ""},
+		},
+	}
+
+	for caseName, c := range cases {
+		t.Run(caseName, func(t *testing.T) {
+			reader, err := NewMapEnvFromNulSeparated(strings.NewReader(c.content))
+			assert.NoError(t, err)
+			actual := make(map[string]string)
+			for _, key := range reader.Keys("") {
+				actual[key] = reader.Get(key)
+			}
+			assert.Equal(t, c.expected, actual)
+		})
+	}
+}
+
+func TestNewProcessEnv(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("/proc/<pid>/environ is only available on Linux")
+	}
+	reader, err := NewProcessEnv(os.Getpid())
+	if !assert.NoError(t, err) {
+		return
+	}
+	path, ok := reader.Lookup("PATH")
+	expected, expectedOk := os.LookupEnv("PATH")
+	assert.Equal(t, expectedOk, ok)
+	assert.Equal(t, expected, path)
+
+	_, err = NewProcessEnv(-1)
+	assert.Error(t, err)
+}
@@ -13,8 +13,34 @@ func (s *OsEnv) Get(envNamed string) string {
 	return os.Getenv(envNamed)
 }
 
+func (s *OsEnv) Lookup(envNamed string) (string, bool) {
+	return os.LookupEnv(envNamed)
+}
+
+// Keys returns the names of the variables in the process environment that start with prefix
 func (s *OsEnv) Keys(prefix string) (out []string) {
-	return SelectKeysWithPrefix(os.Environ(), prefix)
+	environ := os.Environ()
+	names := make([]string, 0, len(environ))
+	for _, entry := range environ {
+		if name, _, ok := splitEnvironEntry(entry); ok {
+			names = append(names, name)
+		}
+	}
+	return SelectKeysWithPrefix(names, prefix)
+}
+
+// LookupEnv gets the value of envNamed from reader and whether it was set at all.
+// Uses the reader's Lookup if it implements EnvLookuper, otherwise, a key is considered set if Keys reports it
+func LookupEnv(reader EnvReader, envNamed string) (value string, ok bool) {
+	if lookuper, isLookuper := reader.(EnvLookuper); isLookuper {
+		return lookuper.Lookup(envNamed)
+	}
+	for _, key := range reader.Keys(envNamed) {
+		if key == envNamed {
+			return reader.Get(envNamed), true
+		}
+	}
+	return
 }
 
 // SelectKeysWithPrefix filters the keys to only include those that contain the prefix
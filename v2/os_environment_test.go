@@ -33,3 +33,11 @@ func TestSelectKeysWithPrefix(t *testing.T) {
 		})
 	}
 }
+
+func TestOsEnv_Keys(t *testing.T) {
+	t.Setenv("GOENVTEST_Host", "h=1")
+	t.Setenv("GOENVTEST_Port", "80")
+	t.Setenv("GOENVTEST_Empty", "")
+	assert.ElementsMatch(t, []string{"GOENVTEST_Host", "GOENVTEST_Port", "GOENVTEST_Empty"}, (&OsEnv{}).Keys("GOENVTEST_"))
+	assert.Equal(t, []string{}, (&OsEnv{}).Keys("GOENVTEST_Missing"))
+}
@@ -1,6 +1,15 @@
 package v2
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrRequired is the cause of a ParseError for fields tagged as required, but not set
+var ErrRequired = errors.New("it is required, but was not set")
+
+// ErrListAndIndexed is the cause of a ParseError for slices set both as a delimited list and with indexed variables
+var ErrListAndIndexed = errors.New("it is set as a list, but variables with indexes are also set, set only one of them")
 
 type ParseError struct {
 	Path        StructEnvPath
@@ -20,3 +29,8 @@ func newParseError(structPath string, envPath string, original error) *ParseErro
 func (p *ParseError) Error() string {
 	return fmt.Sprintf("environment variable '%s' failed to parse because %s", p.Path.EnvPath, p.originalErr.Error())
 }
+
+// Unwrap returns the reason the variable failed to parse
+func (p *ParseError) Unwrap() error {
+	return p.originalErr
+}
@@ -0,0 +1,117 @@
+package v2
+
+import (
+	into_struct "github.com/wojnosystems/go-into-struct"
+	"reflect"
+)
+
+// setPointer fills the pointer field at structFullPath. Pointers stay nil unless they are configured, so that a nil
+// pointer means the feature is not configured. Pointers to structures are allocated when a variable is named after one
+// of their fields, and pointers to values are allocated when the value is set, or has a default.
+// Pointers that are already allocated are filled in place
+func (e *envInternal) setPointer(structFullPath into_struct.Path, candidates []envNameCandidate, tag envTag) (handled bool, err error) {
+	field := structFullPath.Top()
+	elemT := field.Type().Elem()
+	if elemT.Kind() == reflect.Struct && !e.isParseable(reflect.New(elemT).Interface()) {
+		return true, e.setStructPointer(structFullPath, candidates, tag)
+	}
+	if !e.isParseable(reflect.New(elemT).Interface()) {
+		// not a value this Env can parse, let into_struct report it
+		return
+	}
+	handled = true
+	envValue, source, err := e.fieldValue(structFullPath.Parts(), candidates, tag)
+	if err != nil {
+		return
+	}
+	if "" == envValue {
+		if tag.required {
+			err = newParseError(e.structPathString(structFullPath.Parts()), candidates[0].name, ErrRequired)
+		}
+		return
+	}
+	target := field.Value()
+	if target.IsNil() {
+		target = reflect.New(elemT)
+	}
+	_, err = e.parseValue(structFullPath, candidates[0].name, envValue, source, target.Interface())
+	if err != nil {
+		return
+	}
+	field.Value().Set(target)
+	return
+}
+
+// setStructPointer fills a pointer to a structure using the names of the pointer field, as if it were the structure.
+// Unlike fields of a structure that is not behind a pointer, values set here are reported to the SetReceiver with the
+// path to the pointer field, Redis rather than Redis.Host, as into_struct.Path cannot name fields of the target.
+// The name of the variable identifies the field
+func (e *envInternal) setStructPointer(structFullPath into_struct.Path, candidates []envNameCandidate, tag envTag) (err error) {
+	fieldV := structFullPath.Top().Value()
+	configured := e.hasVariablesUnder(candidates)
+	if !configured {
+		configured, err = e.hasAbsoluteVariables(fieldV.Type().Elem(), make(map[reflect.Type]bool))
+		if err != nil {
+			return
+		}
+	}
+	if !configured {
+		if tag.required {
+			err = newParseError(e.structPathString(structFullPath.Parts()), candidates[0].name, ErrRequired)
+		}
+		return
+	}
+	target := fieldV
+	if fieldV.IsNil() {
+		target = reflect.New(fieldV.Type().Elem())
+	}
+	_, err = e.unmarshallNested(structFullPath, e.structPathString(structFullPath.Parts()), candidates, false, nil, target)
+	if err != nil {
+		return
+	}
+	fieldV.Set(target)
+	return
+}
+
+// hasAbsoluteVariables is true when a field tagged absolute in the structure type t, or in a structure inside it, is set.
+// Absolute names do not start with the names of the pointer, so hasVariablesUnder does not find them
+func (e *envInternal) hasAbsoluteVariables(t reflect.Type, visiting map[reflect.Type]bool) (found bool, err error) {
+	if visiting[t] {
+		return
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+	for i := 0; i < t.NumField() && !found; i++ {
+		field := t.Field(i)
+		var tag envTag
+		tag, err = parseEnvTag(field)
+		if err != nil {
+			return
+		}
+		if isSkipped(field, tag) {
+			continue
+		}
+		if tag.absolute {
+			candidates := e.appendFieldNames(nil, field, tag)
+			var value string
+			value, _, err = e.lookupCandidates(candidates)
+			if err != nil {
+				return
+			}
+			if value != "" || e.hasVariablesUnder(candidates) {
+				return true, nil
+			}
+		}
+		fieldT := field.Type
+		for fieldT.Kind() == reflect.Ptr {
+			fieldT = fieldT.Elem()
+		}
+		if fieldT.Kind() == reflect.Struct && !e.isParseable(reflect.New(fieldT).Interface()) {
+			found, err = e.hasAbsoluteVariables(fieldT, visiting)
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,123 @@
+package v2
+
+import (
+	"errors"
+	into_struct "github.com/wojnosystems/go-into-struct"
+	"reflect"
+	"strings"
+)
+
+// isScalarMap is true for maps with string keys and values that are parsed as a whole, such as map[string]string
+func (e *envInternal) isScalarMap(t reflect.Type) bool {
+	return t.Kind() == reflect.Map && t.Key().Kind() == reflect.String &&
+		!e.isParseable(reflect.New(t).Interface()) &&
+		e.isParseable(reflect.New(t.Elem()).Interface())
+}
+
+// scalarMapEntry is the value of a key in a map, before it is parsed
+type scalarMapEntry struct {
+	value  string
+	source envValueSource
+	// listed is true when the entry was read from a list of key=value items, rather than a variable named after the key
+	listed bool
+}
+
+var errNotKeyValue = errors.New("every item must be a key=value pair")
+
+// setScalarMap fills the map at structFullPath from a list of key=value items in the variable named after the map,
+// LABELS=team=core,tier=1, and from variables named after the map and a key, LABELS_team=core. The key is everything
+// after the name of the map and the Field separator. With a file suffix, LABELS_team_FILE reads the value of team from a
+// file. Variables named after a key take precedence over the same key in the list. Keys that are not set are kept,
+// if the map already has them. Each entry is reported to the SetReceiver
+func (e *envInternal) setScalarMap(structFullPath into_struct.Path, candidates []envNameCandidate, tag envTag) (err error) {
+	parts := structFullPath.Parts()
+	var keys []string
+	entries := make(map[string]scalarMapEntry)
+	add := func(key string, entry scalarMapEntry) {
+		if _, exists := entries[key]; !exists {
+			keys = append(keys, key)
+		}
+		entries[key] = entry
+	}
+
+	envValue, source, err := e.fieldValue(parts, candidates, tag)
+	if err != nil {
+		return
+	}
+	if envValue != "" {
+		items := []string{envValue}
+		if separator := e.listSeparatorFor(tag); separator != "" {
+			items, err = splitList(envValue, separator)
+			if err != nil {
+				return newParseError(e.structPathString(parts), source.envName, err)
+			}
+		}
+		for _, item := range items {
+			if item == "" {
+				continue
+			}
+			equals := strings.IndexByte(item, '=')
+			if equals == -1 || strings.TrimSpace(item[:equals]) == "" {
+				return newParseError(e.structPathString(parts), source.envName, errNotKeyValue)
+			}
+			add(strings.TrimSpace(item[:equals]), scalarMapEntry{value: item[equals+1:], source: source, listed: true})
+		}
+	}
+
+	named := make(map[string]bool)
+	for _, candidate := range candidates {
+		prefix := e.separators.appendField(candidate.envName, "").name
+		for _, name := range e.keys(prefix) {
+			if e.fileSuffix != "" && name == candidate.name+e.fileSuffix {
+				// the file holding the list for the whole map, read by fieldValue
+				continue
+			}
+			key := name[len(prefix):]
+			if e.fileSuffix != "" && strings.HasSuffix(key, e.fileSuffix) {
+				// LABELS_team_FILE names the file holding the value of the key team, unless LABELS_team is set
+				key = key[:len(key)-len(e.fileSuffix)]
+				name = name[:len(name)-len(e.fileSuffix)]
+			}
+			if key == "" || named[key] {
+				continue
+			}
+			named[key] = true
+			var entry scalarMapEntry
+			entry.value, entry.source, err = e.lookupValue(name)
+			if err != nil {
+				return newParseError(e.structPathString(parts), entry.source.envName, err)
+			}
+			entry.source.secret, entry.source.deprecated, entry.source.network = tag.secret, candidate.deprecated, tag.network
+			if entry.value != "" {
+				add(key, entry)
+			}
+		}
+	}
+
+	if len(keys) == 0 {
+		if tag.required {
+			err = newParseError(e.structPathString(parts), candidates[0].name, ErrRequired)
+		}
+		return
+	}
+	mapV := structFullPath.Top().Value()
+	if mapV.IsNil() {
+		mapV.Set(reflect.MakeMap(mapV.Type()))
+	}
+	for _, key := range keys {
+		entry := entries[key]
+		element := reflect.New(mapV.Type().Elem())
+		_, err = e.unmarshalValue(e.structPathString(parts)+"["+key+"]", entry.value, entry.source, element.Interface())
+		if err != nil {
+			return
+		}
+		mapV.SetMapIndex(reflect.ValueOf(key).Convert(mapV.Type().Key()), element.Elem())
+		reportedValue := entry.source.reportedValue(entry.value)
+		if entry.listed {
+			// the name of the variable does not identify the entry
+			reportedValue = key + "=" + reportedValue
+		}
+		e.receive(structFullPath, candidates[0].name, entry.source, reportedValue)
+	}
+	return
+}
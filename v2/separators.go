@@ -0,0 +1,89 @@
+package v2
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Separators are placed between the parts of an environment variable name
+type Separators struct {
+	// Field separates the name of a field from the name of the structure that contains it: "container_field"
+	Field string
+	// IndexStart separates the name of a slice from the index of an element: "slice_5"
+	IndexStart string
+	// IndexEnd follows the index of a slice element: "slice_5_". The Field separator is not added after IndexEnd,
+	// unless IndexEnd is blank
+	IndexEnd string
+}
+
+// DefaultSeparators names variables like Databases_0_Host
+var DefaultSeparators = Separators{
+	Field:      "_",
+	IndexStart: "_",
+	IndexEnd:   "_",
+}
+
+// envName is an environment variable name that is being built up one part at a time
+type envName struct {
+	name string
+	// separated is true when the next part can be appended without a separator: at the start or after a separator
+	separated bool
+	// indexed is true when name ends with the index of a slice element
+	indexed bool
+}
+
+// rootEnvName is where every name starts
+var rootEnvName = envName{separated: true}
+
+// appendField adds the name of a field, or a prefix, to parent
+func (s Separators) appendField(parent envName, field string) envName {
+	name := parent.name
+	if !parent.separated {
+		name += s.Field
+	}
+	return envName{
+		name:      name + field,
+		separated: strings.HasSuffix(field, s.Field),
+	}
+}
+
+// appendIndex adds the index of a slice element to parent, which should be the name of the slice.
+// Nested indexes share the separator between them: "matrix_0_1_"
+func (s Separators) appendIndex(parent envName, index int) envName {
+	return s.appendIndexText(parent, strconv.Itoa(index))
+}
+
+// appendIndexText adds an index, already formatted as text, to parent
+func (s Separators) appendIndexText(parent envName, index string) envName {
+	return envName{
+		name:      s.indexPrefix(parent) + index + s.IndexEnd,
+		separated: s.IndexEnd != "",
+		indexed:   true,
+	}
+}
+
+// indexPrefix is the start of the names of the elements of the slice named parent, up to the index
+func (s Separators) indexPrefix(parent envName) string {
+	if parent.indexed && parent.separated {
+		return parent.name
+	}
+	return parent.name + s.IndexStart
+}
+
+// indexFollowing is what follows the index in the names of slice elements, as matched by indexRegexp
+func (s Separators) indexFollowing() string {
+	if s.IndexEnd == "" {
+		return s.Field
+	}
+	return s.IndexEnd
+}
+
+// indexRegexp matches the index at the start of the remainder of a variable name after the name of a slice and IndexStart
+func (s Separators) indexRegexp() *regexp.Regexp {
+	following := s.indexFollowing()
+	if following == "" {
+		return regexp.MustCompile(`^(\d+)`)
+	}
+	return regexp.MustCompile(`^(\d+)(?:` + regexp.QuoteMeta(following) + `|$)`)
+}
@@ -0,0 +1,86 @@
+package v2
+
+import (
+	into_struct "github.com/wojnosystems/go-into-struct"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// isStructMap is true for maps with string keys and structure values that are filled one field at a time,
+// such as map[string]DbConfig
+func (e *envInternal) isStructMap(t reflect.Type) bool {
+	return t.Kind() == reflect.Map && t.Key().Kind() == reflect.String && t.Elem().Kind() == reflect.Struct &&
+		!e.isParseable(reflect.New(t).Interface()) &&
+		!e.isParseable(reflect.New(t.Elem()).Interface())
+}
+
+// setStructMap fills the map at structFullPath with an element for every key found in the variable names:
+// Databases_primary_Host sets the Host of the element with the key "primary". Keys are the text between the name
+// of the map and the next Field separator, so keys cannot contain the separator. Elements are only added if at least
+// one of their fields is set. Elements already in the map are kept, and updated if their key is found
+func (e *envInternal) setStructMap(structFullPath into_struct.Path, candidates []envNameCandidate, tag envTag) (err error) {
+	field := structFullPath.Top()
+	mapT := field.Type()
+	mapV := field.Value()
+	for _, key := range e.structMapKeys(candidates) {
+		element := reflect.New(mapT.Elem())
+		keyV := reflect.ValueOf(key).Convert(mapT.Key())
+		if existing := mapV.MapIndex(keyV); existing.IsValid() {
+			element.Elem().Set(existing)
+		}
+		roots := make([]envNameCandidate, len(candidates))
+		for i, candidate := range candidates {
+			roots[i] = envNameCandidate{
+				envName:    e.separators.appendField(candidate.envName, key),
+				deprecated: candidate.deprecated,
+			}
+		}
+		var sets int
+		sets, err = e.unmarshallNested(structFullPath, e.structPathString(structFullPath.Parts())+"["+key+"]", roots, true, nil, element)
+		if err != nil {
+			return
+		}
+		if sets == 0 {
+			continue
+		}
+		if mapV.IsNil() {
+			mapV.Set(reflect.MakeMap(mapT))
+		}
+		mapV.SetMapIndex(keyV, element.Elem())
+	}
+	if tag.required && mapV.Len() == 0 {
+		err = newParseError(e.structPathString(structFullPath.Parts()), candidates[0].name, ErrRequired)
+	}
+	return
+}
+
+// structMapKeys lists the keys of the map named by any of the candidates, in order.
+// When names are case-insensitive, keys that differ only by case are the same key
+func (e *envInternal) structMapKeys(candidates []envNameCandidate) (keys []string) {
+	found := make(map[string]bool)
+	for _, candidate := range candidates {
+		prefix := e.separators.appendField(candidate.envName, "").name
+		for _, name := range e.keys(prefix) {
+			rest := name[len(prefix):]
+			end := strings.Index(rest, e.separators.Field)
+			if end <= 0 {
+				continue
+			}
+			key := rest[:end]
+			if e.caseInsensitive {
+				if found[strings.ToLower(key)] {
+					continue
+				}
+				found[strings.ToLower(key)] = true
+			} else if found[key] {
+				continue
+			} else {
+				found[key] = true
+			}
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return
+}
@@ -0,0 +1,193 @@
+package v2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"strconv"
+)
+
+// NewJSONEnv creates a reader from a JSON document. Nested objects and arrays are flattened into variable names using
+// the same scheme Env uses to name fields, so the same structure can be read from a document or from the environment:
+//
+//	{"Name": "SuperServer", "Databases": [{"Host": "example.com", "NEST": {"TIMEOUT": "30s"}}]}
+//
+// becomes Name=SuperServer, Databases_0_Host=example.com and Databases_0_NEST_TIMEOUT=30s.
+// Numbers and booleans are passed along as written, so type errors are reported when unmarshalling, as with any
+// other variable. Nulls are treated as not being set. The top level of the document must be an object
+func NewJSONEnv(src io.Reader) (reader *MapEnv, err error) {
+	return NewJSONEnvWithSeparators(src, DefaultSeparators)
+}
+
+// NewJSONEnvWithSeparators is NewJSONEnv for an Env configured with WithSeparators
+func NewJSONEnvWithSeparators(src io.Reader, separators Separators) (reader *MapEnv, err error) {
+	decoder := json.NewDecoder(src)
+	decoder.UseNumber()
+	var document interface{}
+	err = decoder.Decode(&document)
+	if err != nil {
+		return
+	}
+	// More misses data that closes a bracket, such as {"a":1}}, so the rest must be empty
+	if _, tokenErr := decoder.Token(); tokenErr != io.EOF {
+		err = errors.New("json: unexpected data after the top-level object")
+		return
+	}
+	if _, ok := document.(map[string]interface{}); !ok {
+		err = errStructuredNotObject
+		return
+	}
+	f := newStructuredFlattener(separators)
+	err = f.flattenJSON(rootEnvName, document)
+	if err != nil {
+		return
+	}
+	reader = NewMapEnv(f.values)
+	return
+}
+
+// NewYAMLEnv creates a reader from a YAML document. Nested mappings and sequences are flattened into variable names
+// the same way as NewJSONEnv. Scalars are passed along exactly as written, so "30s" and "0755" are not reinterpreted.
+// Anchors and aliases are supported. The top level of the document must be a mapping. The source must contain a single
+// document: a second document with content, after ---, is an error rather than being ignored
+func NewYAMLEnv(src io.Reader) (reader *MapEnv, err error) {
+	return NewYAMLEnvWithSeparators(src, DefaultSeparators)
+}
+
+// isEmptyYAMLDocument is true for documents without content, such as the one after a trailing ---
+func isEmptyYAMLDocument(document *yaml.Node) bool {
+	if len(document.Content) == 0 {
+		return true
+	}
+	root := document.Content[0]
+	return len(document.Content) == 1 && root.Kind == yaml.ScalarNode && root.Tag == "!!null" && root.Value == ""
+}
+
+// NewYAMLEnvWithSeparators is NewYAMLEnv for an Env configured with WithSeparators
+func NewYAMLEnvWithSeparators(src io.Reader, separators Separators) (reader *MapEnv, err error) {
+	var document yaml.Node
+	decoder := yaml.NewDecoder(src)
+	err = decoder.Decode(&document)
+	if err == io.EOF {
+		return NewMapEnv(nil), nil
+	}
+	if err != nil {
+		return
+	}
+	for {
+		var next yaml.Node
+		nextErr := decoder.Decode(&next)
+		if nextErr == io.EOF {
+			break
+		}
+		if nextErr != nil || !isEmptyYAMLDocument(&next) {
+			err = errors.New("yaml: only one document is supported, but the source contains more")
+			return
+		}
+	}
+	root := &document
+	if root.Kind == yaml.DocumentNode && len(root.Content) == 1 {
+		root = root.Content[0]
+	}
+	if root.Kind != yaml.MappingNode {
+		err = errStructuredNotObject
+		return
+	}
+	f := newStructuredFlattener(separators)
+	err = f.flattenYAML(rootEnvName, root)
+	if err != nil {
+		return
+	}
+	reader = NewMapEnv(f.values)
+	return
+}
+
+var errStructuredNotObject = errors.New("the top level of the document must be an object")
+
+// structuredFlattener converts nested documents into variable names and values
+type structuredFlattener struct {
+	values     map[string]string
+	separators Separators
+}
+
+func newStructuredFlattener(separators Separators) *structuredFlattener {
+	return &structuredFlattener{
+		values:     make(map[string]string),
+		separators: separators,
+	}
+}
+
+func (f *structuredFlattener) flattenJSON(name envName, value interface{}) (err error) {
+	switch v := value.(type) {
+	case nil:
+		return
+	case map[string]interface{}:
+		for key, child := range v {
+			err = f.flattenJSON(f.separators.appendField(name, key), child)
+			if err != nil {
+				return
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			err = f.flattenJSON(f.separators.appendIndex(name, i), child)
+			if err != nil {
+				return
+			}
+		}
+	case string:
+		err = f.set(name, v)
+	case json.Number:
+		err = f.set(name, v.String())
+	case bool:
+		err = f.set(name, strconv.FormatBool(v))
+	default:
+		err = fmt.Errorf("unsupported JSON value for '%s'", name.name)
+	}
+	return
+}
+
+func (f *structuredFlattener) flattenYAML(name envName, node *yaml.Node) (err error) {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return f.flattenYAML(name, node.Alias)
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if key.Kind != yaml.ScalarNode {
+				return fmt.Errorf("yaml: line %d: keys must be scalars", key.Line)
+			}
+			err = f.flattenYAML(f.separators.appendField(name, key.Value), node.Content[i+1])
+			if err != nil {
+				return
+			}
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			err = f.flattenYAML(f.separators.appendIndex(name, i), child)
+			if err != nil {
+				return
+			}
+		}
+	case yaml.ScalarNode:
+		if node.ShortTag() == "!!null" {
+			return
+		}
+		err = f.set(name, node.Value)
+	default:
+		err = fmt.Errorf("yaml: line %d: unsupported value for '%s'", node.Line, name.name)
+	}
+	return
+}
+
+// set records the value of a variable. Different paths in a document can result in the same variable name,
+// such as {"a_b": 1, "a": {"b": 2}}, which is reported as an error instead of silently picking one
+func (f *structuredFlattener) set(name envName, value string) error {
+	if _, exists := f.values[name.name]; exists {
+		return fmt.Errorf("'%s' is set more than once in the document", name.name)
+	}
+	f.values[name.name] = value
+	return nil
+}
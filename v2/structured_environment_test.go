@@ -0,0 +1,206 @@
+package v2
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wojnosystems/go-optional/v2"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewJSONEnv(t *testing.T) {
+	cases := map[string]struct {
+		content  string
+		expected map[string]string
+	}{
+		"empty object": {
+			content:  `{}`,
+			expected: map[string]string{},
+		},
+		"scalars": {
+			content:  `{"Name": "SuperServer", "ThreadCount": 8, "Ratio": 0.5, "Debug": true, "Missing": null}`,
+			expected: map[string]string{"Name": "SuperServer", "ThreadCount": "8", "Ratio": "0.5", "Debug": "true"},
+		},
+		"nested": {
+			content: `{"Databases": [{"Host": "example.com", "NEST": {"TIMEOUT": "30s"}}, {"Host": "replica"}], "pet_names": ["Frankie", "Charlie"]}`,
+			expected: map[string]string{
+				"Databases_0_Host":         "example.com",
+				"Databases_0_NEST_TIMEOUT": "30s",
+				"Databases_1_Host":         "replica",
+				"pet_names_0_":             "Frankie",
+				"pet_names_1_":             "Charlie",
+			},
+		},
+		"nested arrays": {
+			content:  `{"Matrix": [[1, 2], [3]]}`,
+			expected: map[string]string{"Matrix_0_0_": "1", "Matrix_0_1_": "2", "Matrix_1_0_": "3"},
+		},
+	}
+
+	for caseName, c := range cases {
+		t.Run(caseName, func(t *testing.T) {
+			reader, err := NewJSONEnv(strings.NewReader(c.content))
+			if !assert.NoError(t, err) {
+				return
+			}
+			actual := make(map[string]string)
+			for _, key := range reader.Keys("") {
+				actual[key] = reader.Get(key)
+			}
+			assert.Equal(t, c.expected, actual)
+		})
+	}
+}
+
+func TestNewJSONEnvErrors(t *testing.T) {
+	cases := map[string]struct {
+		content  string
+		expected string
+	}{
+		"not an object": {
+			content:  `[1, 2]`,
+			expected: "the top level of the document must be an object",
+		},
+		"duplicate names": {
+			content:  `{"a_b": 1, "a": {"b": 2}}`,
+			expected: "'a_b' is set more than once in the document",
+		},
+		"trailing data": {
+			content:  `{} {}`,
+			expected: "json: unexpected data after the top-level object",
+		},
+		"trailing closing bracket": {
+			content:  `{"a":1}}`,
+			expected: "json: unexpected data after the top-level object",
+		},
+		"malformed": {
+			content:  `{"a": }`,
+			expected: "invalid character '}' looking for beginning of value",
+		},
+	}
+
+	for caseName, c := range cases {
+		t.Run(caseName, func(t *testing.T) {
+			_, err := NewJSONEnv(strings.NewReader(c.content))
+			assert.EqualError(t, err, c.expected)
+		})
+	}
+}
+
+func TestNewYAMLEnv(t *testing.T) {
+	cases := map[string]struct {
+		content  string
+		expected map[string]string
+	}{
+		"empty": {
+			expected: map[string]string{},
+		},
+		"trailing document marker": {
+			content:  "---\nName: a\n---\n",
+			expected: map[string]string{"Name": "a"},
+		},
+		"scalars kept as written": {
+			content:  "Name: SuperServer\nMode: 0755\nTimeout: 30s\nMissing: ~\nCert: |\n  line1\n  line2\n",
+			expected: map[string]string{"Name": "SuperServer", "Mode": "0755", "Timeout": "30s", "Cert": "line1\nline2\n"},
+		},
+		"nested with aliases": {
+			content: `
+defaults: &defaults
+  Host: example.com
+Databases:
+  - *defaults
+  - Host: replica
+    NEST:
+      TIMEOUT: 30s
+`,
+			expected: map[string]string{
+				"defaults_Host":            "example.com",
+				"Databases_0_Host":         "example.com",
+				"Databases_1_Host":         "replica",
+				"Databases_1_NEST_TIMEOUT": "30s",
+			},
+		},
+	}
+
+	for caseName, c := range cases {
+		t.Run(caseName, func(t *testing.T) {
+			reader, err := NewYAMLEnv(strings.NewReader(c.content))
+			if !assert.NoError(t, err) {
+				return
+			}
+			actual := make(map[string]string)
+			for _, key := range reader.Keys("") {
+				actual[key] = reader.Get(key)
+			}
+			assert.Equal(t, c.expected, actual)
+		})
+	}
+}
+
+func TestNewYAMLEnvErrors(t *testing.T) {
+	cases := map[string]struct {
+		content  string
+		expected string
+	}{
+		"not a mapping": {
+			content:  "- 1\n- 2\n",
+			expected: "the top level of the document must be an object",
+		},
+		"complex keys": {
+			content:  "? [a, b]\n: 1\n",
+			expected: "yaml: line 1: keys must be scalars",
+		},
+		"several documents": {
+			content:  "Name: a\n---\nName: b\n",
+			expected: "yaml: only one document is supported, but the source contains more",
+		},
+	}
+
+	for caseName, c := range cases {
+		t.Run(caseName, func(t *testing.T) {
+			_, err := NewYAMLEnv(strings.NewReader(c.content))
+			assert.EqualError(t, err, c.expected)
+		})
+	}
+}
+
+func TestStructuredEnv_Unmarshall(t *testing.T) {
+	document, err := NewYAMLEnv(strings.NewReader(`
+Name: default
+Databases:
+  - Host: example.com
+    NEST:
+      TIMEOUT: 30s
+`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	reader := NewLayeredEnv(&envMock{mock: map[string]string{"Name": "override"}}, document)
+	actual := &appConfigMock{}
+	err = NewWithEnvReader(reader).Unmarshall(actual)
+	assert.NoError(t, err)
+	assert.True(t, appConfigMock{
+		Name: optional.StringFrom("override"),
+		Databases: []dbConfigMock{
+			{
+				Host:   optional.StringFrom("example.com"),
+				Nested: nestedDbConfigMock{ConnTimeout: optional.DurationFrom(30 * time.Second)},
+			},
+		},
+	}.IsEqual(actual))
+
+	document, err = NewJSONEnv(strings.NewReader(`{"ThreadCount": "many"}`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = NewWithEnvReader(document).Unmarshall(&appConfigMock{})
+	assert.IsType(t, &ParseError{}, err)
+}
+
+func TestNewJSONEnvWithSeparators(t *testing.T) {
+	reader, err := NewJSONEnvWithSeparators(strings.NewReader(`{"Databases": [{"NEST": {"TIMEOUT": "30s"}}]}`), Separators{Field: "__", IndexStart: "__", IndexEnd: "__"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"Databases__0__NEST__TIMEOUT"}, reader.Keys(""))
+}
@@ -0,0 +1,31 @@
+package v2
+
+import (
+	"errors"
+	"os"
+)
+
+// SystemdCredentialsDirectoryEnvName is the variable systemd uses to tell a service where its credentials are
+const SystemdCredentialsDirectoryEnvName = "CREDENTIALS_DIRECTORY"
+
+// ErrNoSystemdCredentials is returned when the process was not started with any systemd credentials
+var ErrNoSystemdCredentials = errors.New(SystemdCredentialsDirectoryEnvName + " is not set, the service has no systemd credentials")
+
+// NewSystemdCredentialsEnv creates a reader with the credentials systemd passed to this service using LoadCredential=,
+// SetCredential= and similar settings. Each credential is a variable named after the credential. The contents,
+// without trailing newlines, is the value.
+//
+// Layer this with OsEnv to read secrets from credentials and everything else from the environment:
+//
+//	credentials, err := NewSystemdCredentialsEnv()
+//	reader := NewLayeredEnv(credentials, &OsEnv{})
+//
+// Returns ErrNoSystemdCredentials if the CREDENTIALS_DIRECTORY variable is not set
+func NewSystemdCredentialsEnv() (reader *MapEnv, err error) {
+	dir := os.Getenv(SystemdCredentialsDirectoryEnvName)
+	if dir == "" {
+		err = ErrNoSystemdCredentials
+		return
+	}
+	return NewDirEnv(dir)
+}
@@ -0,0 +1,55 @@
+package v2
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wojnosystems/go-optional/v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewSystemdCredentialsEnv(t *testing.T) {
+	original, wasSet := os.LookupEnv(SystemdCredentialsDirectoryEnvName)
+	defer func() {
+		if wasSet {
+			_ = os.Setenv(SystemdCredentialsDirectoryEnvName, original)
+		} else {
+			_ = os.Unsetenv(SystemdCredentialsDirectoryEnvName)
+		}
+	}()
+
+	t.Run("not set", func(t *testing.T) {
+		assert.NoError(t, os.Unsetenv(SystemdCredentialsDirectoryEnvName))
+		_, err := NewSystemdCredentialsEnv()
+		assert.Equal(t, ErrNoSystemdCredentials, err)
+	})
+	t.Run("layered with environment", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "credentials")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer func() {
+			_ = os.RemoveAll(dir)
+		}()
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "Databases_0_Password"), []byte("s3cr3t\n"), 0400))
+		assert.NoError(t, os.Setenv(SystemdCredentialsDirectoryEnvName, dir))
+
+		credentials, err := NewSystemdCredentialsEnv()
+		if !assert.NoError(t, err) {
+			return
+		}
+		reader := NewLayeredEnv(credentials, &envMock{mock: map[string]string{"Databases_0_Host": "example.com"}})
+		actual := &appConfigMock{}
+		err = NewWithEnvReader(reader).Unmarshall(actual)
+		assert.NoError(t, err)
+		assert.True(t, appConfigMock{
+			Databases: []dbConfigMock{
+				{
+					Host:     optional.StringFrom("example.com"),
+					Password: optional.StringFrom("s3cr3t"),
+				},
+			},
+		}.IsEqual(actual))
+	})
+}
@@ -0,0 +1,25 @@
+package v2
+
+import "fmt"
+
+// TagError is returned when the env tag of a field in the destination structure is invalid.
+// This is a mistake in the program, not in the environment
+type TagError struct {
+	// StructPath is the path to the field with the invalid tag
+	StructPath string
+	// Tag is the value of the env tag
+	Tag         string
+	originalErr error
+}
+
+func newTagError(structPath string, tag string, original error) *TagError {
+	return &TagError{
+		StructPath:  structPath,
+		Tag:         tag,
+		originalErr: original,
+	}
+}
+
+func (t *TagError) Error() string {
+	return fmt.Sprintf("env tag `%s` on field '%s' is invalid because %s", t.Tag, t.StructPath, t.originalErr.Error())
+}
@@ -0,0 +1,75 @@
+package v2
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+)
+
+// envValueSource describes where the value for a field came from
+type envValueSource struct {
+	// envName is the name of the environment variable that provided the value
+	envName string
+	// fileName is the name of the file the value was read from, or blank if the value was read from envName directly
+	fileName string
+	// secret is true when the field is tagged as secret
+	secret bool
+	// isDefault is true when the variable was not set and the value is the default from the env tag
+	isDefault bool
+	// deprecated is true when envName is a deprecated name for the field
+	deprecated bool
+	// network are the options from the field's tag for network values
+	network networkOptions
+}
+
+// RedactedValue is passed to the SetReceiver in place of values from fields tagged as secret
+const RedactedValue = "<redacted>"
+
+// reportedValue is the value that is safe to pass along to the SetReceiver.
+// Values read from files are assumed to be secret, so the name of the file is reported instead
+func (s envValueSource) reportedValue(value string) string {
+	if s.fileName != "" {
+		return s.fileName
+	}
+	if s.secret {
+		return RedactedValue
+	}
+	return value
+}
+
+// redactError replaces errors that could contain the value with one that does not, if the value is secret
+func (s envValueSource) redactError(err error, valueDst interface{}) error {
+	if s.fileName == "" && !s.secret {
+		return err
+	}
+	return &redactedValueError{
+		fileName: s.fileName,
+		typeName: reflect.TypeOf(valueDst).Elem().String(),
+	}
+}
+
+// redactedValueError is returned when a secret value cannot be parsed.
+// The original error is deliberately discarded as parsers typically include the value in the error message
+type redactedValueError struct {
+	// fileName is the file the value was read from, blank if it was not read from a file
+	fileName string
+	typeName string
+}
+
+func (v *redactedValueError) Error() string {
+	if v.fileName == "" {
+		return fmt.Sprintf("the secret value is not a valid %s", v.typeName)
+	}
+	return fmt.Sprintf("the contents of file '%s' are not a valid %s", v.fileName, v.typeName)
+}
+
+// readValueFile reads the whole file at fileName and removes any trailing newlines
+func readValueFile(fileName string) (value string, err error) {
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return
+	}
+	value = strings.TrimRight(string(content), "\r\n")
+	return
+}